@@ -11,8 +11,9 @@ import (
 
 type MemCache struct {
 	items           *gomap.Map
-	ticker          *time.Ticker
+	ticker          goutils.Ticker
 	recycleInterval time.Duration
+	clock           goutils.Clock
 }
 
 type cachedItem struct {
@@ -27,15 +28,16 @@ func NewMemCache(opts ...MemCacheOption) *MemCache {
 	c := &MemCache{
 		items:           gomap.New(),
 		recycleInterval: time.Hour,
+		clock:           goutils.GetClock(),
 	}
 
 	for _, opt := range opts {
 		opt(c)
 	}
 
+	c.ticker = c.clock.NewTicker(c.recycleInterval)
 	go func() {
-		c.ticker = time.NewTicker(c.recycleInterval)
-		for range c.ticker.C {
+		for range c.ticker.C() {
 			c.removeExpired()
 		}
 	}()
@@ -49,8 +51,15 @@ func WithRecycleInterval(interval time.Duration) MemCacheOption {
 	}
 }
 
+// WithClock sets the clock to determine expiration.
+func WithClock(clock goutils.Clock) MemCacheOption {
+	return func(mc *MemCache) {
+		mc.clock = clock
+	}
+}
+
 func (c *MemCache) removeExpired() {
-	now := goutils.GetNow()
+	now := c.clock.Now()
 	for _, result := range c.items.GetItemsUnordered() {
 		item := result.Value.(*cachedItem)
 		if item == nil {
@@ -84,7 +93,7 @@ func (c *MemCache) GetOrError(key string) (interface{}, error) {
 		if item == nil {
 			return nil, errors.New("Unexpected values")
 		}
-		if item.ExpireTime.Before(time.Now()) {
+		if item.ExpireTime.Before(c.clock.Now()) {
 			// This item had expired.
 			c.items.Delete(key)
 			return nil, errors.New("Expired")
@@ -104,11 +113,11 @@ func (c *MemCache) UpsertWithTTL(key string, val interface{}, ttl time.Duration)
 			return
 		}
 		item.Payload = val
-		item.ExpireTime = time.Now().Add(ttl)
+		item.ExpireTime = c.clock.Now().Add(ttl)
 	} else {
 		c.items.Set(key, &cachedItem{
 			Payload:    val,
-			ExpireTime: time.Now().Add(ttl),
+			ExpireTime: c.clock.Now().Add(ttl),
 		})
 	}
 }
@@ -0,0 +1,24 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	goutils "github.com/hoveychen/go-utils"
+)
+
+func TestMemCacheExpire(t *testing.T) {
+	clock := goutils.NewFakeClock(time.Date(2018, 1, 1, 0, 0, 0, 0, goutils.ChinaTimezone))
+	c := NewMemCache(WithClock(clock), WithRecycleInterval(time.Minute))
+	defer c.Stop()
+
+	c.UpsertWithTTL("key", "value", time.Minute)
+	clock.Advance(30 * time.Second)
+	if c.Get("key") != "value" {
+		t.Error("Value expired too early")
+	}
+	clock.Advance(time.Minute)
+	if _, err := c.GetOrError("key"); err == nil {
+		t.Error("Value not expired")
+	}
+}
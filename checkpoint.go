@@ -0,0 +1,127 @@
+package goutils
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Checkpoint is the progress of a poller, e.g. the high-water mark of Monitor.
+// ID breaks the tie of records sharing the same time, and an empty ID means
+// all the records at Time are processed.
+// NOTE: IDs are compared as strings, so numeric IDs must be padded to the same
+// width, e.g. "009" < "010". ObjectId hex strings are fine.
+type Checkpoint struct {
+	Time time.Time `json:"time" bson:"time"`
+	ID   string    `json:"id,omitempty" bson:"id,omitempty"`
+}
+
+// After returns whether cp is later than o, compared by Time and then by ID.
+// At the same time, an empty ID is later than any others.
+func (cp Checkpoint) After(o Checkpoint) bool {
+	if !cp.Time.Equal(o.Time) {
+		return cp.Time.After(o.Time)
+	}
+	if cp.ID == "" || o.ID == "" {
+		return cp.ID == "" && o.ID != ""
+	}
+	return cp.ID > o.ID
+}
+
+// CheckpointStore persists checkpoints by key, so that pollers can resume
+// after restarting.
+type CheckpointStore interface {
+	// Load returns nil if no checkpoint is saved for the key.
+	Load(key string) (*Checkpoint, error)
+	Save(key string, cp *Checkpoint) error
+}
+
+// FileCheckpointStore keeps all the checkpoints in a single local json file.
+// The file is synced and replaced atomically on every save, so it's never left
+// broken by a crash. A broken file, e.g. edited by hand, fails both Load() and
+// Save() until it's fixed, instead of losing the checkpoints in it.
+type FileCheckpointStore struct {
+	path string
+	sync.Mutex
+}
+
+// NewFileCheckpointStore returns a store backed by the file in path. The file
+// is created on the first save.
+func NewFileCheckpointStore(path string) *FileCheckpointStore {
+	return &FileCheckpointStore{path: path}
+}
+
+func (s *FileCheckpointStore) readAll() (map[string]*Checkpoint, error) {
+	ret := map[string]*Checkpoint{}
+	data, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return ret, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "Read checkpoints")
+	}
+	if err := json.Unmarshal(data, &ret); err != nil {
+		return nil, errors.Wrapf(err, "Parse checkpoints %s", s.path)
+	}
+	return ret, nil
+}
+
+func (s *FileCheckpointStore) Load(key string) (*Checkpoint, error) {
+	s.Lock()
+	defer s.Unlock()
+	all, err := s.readAll()
+	if err != nil {
+		return nil, err
+	}
+	return all[key], nil
+}
+
+func (s *FileCheckpointStore) Save(key string, cp *Checkpoint) error {
+	s.Lock()
+	defer s.Unlock()
+	all, err := s.readAll()
+	if err != nil {
+		return err
+	}
+	all[key] = cp
+	data, err := json.MarshalIndent(all, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "Marshal checkpoints")
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path)+".tmp")
+	if err != nil {
+		return errors.Wrap(err, "Create temp file")
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "Write checkpoints")
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "Sync checkpoints")
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrap(err, "Write checkpoints")
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return errors.Wrap(err, "Replace checkpoints")
+	}
+	// The rename itself is only durable once the directory is synced.
+	return errors.Wrap(syncDir(filepath.Dir(s.path)), "Sync checkpoints")
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
@@ -0,0 +1,247 @@
+package goutils
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock provides the current time and timers. It's an abstraction over the
+// time package, so that time-dependent logic can be tested without sleeping.
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+	NewTicker(d time.Duration) Ticker
+}
+
+// Timer is the same as time.Timer, except the channel is returned by C().
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// Ticker is the same as time.Ticker, except the channel is returned by C().
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+var (
+	defaultClock Clock = RealClock{}
+	clockLock    sync.RWMutex
+)
+
+// GetClock returns the clock used by GetNow(), and by the time-dependent types
+// when no clock is specified.
+func GetClock() Clock {
+	clockLock.RLock()
+	defer clockLock.RUnlock()
+	return defaultClock
+}
+
+// SetClock replaces the default clock, which is usually a FakeClock in tests.
+func SetClock(c Clock) {
+	clockLock.Lock()
+	defer clockLock.Unlock()
+	defaultClock = c
+}
+
+// RealClock is the Clock backed by the time package.
+type RealClock struct{}
+
+type realTimer struct {
+	*time.Timer
+}
+
+type realTicker struct {
+	*time.Ticker
+}
+
+func (RealClock) Now() time.Time {
+	return time.Now()
+}
+
+func (RealClock) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+func (RealClock) NewTicker(d time.Duration) Ticker {
+	return realTicker{time.NewTicker(d)}
+}
+
+func (t realTimer) C() <-chan time.Time {
+	return t.Timer.C
+}
+
+func (t realTicker) C() <-chan time.Time {
+	return t.Ticker.C
+}
+
+// FakeClock is a Clock for testing, whose time only moves by Advance() or Set().
+// Timers and tickers fire synchronously during advancing, in the order of their
+// due time.
+// Example usage:
+//   clock := NewFakeClock(time.Date(2018, 1, 1, 0, 0, 0, 0, ChinaTimezone))
+//   c := cache.NewMemCache(cache.WithClock(clock))
+//   c.UpsertWithTTL("key", "value", time.Minute)
+//   clock.Advance(time.Minute * 2)
+//   c.Get("key") // => nil
+type FakeClock struct {
+	sync.Mutex
+	now     time.Time
+	waiters []*fakeWaiter
+	changed *sync.Cond
+}
+
+type fakeWaiter struct {
+	clock  *FakeClock
+	until  time.Time
+	period time.Duration
+	c      chan time.Time
+}
+
+type fakeTimer struct {
+	*fakeWaiter
+}
+
+type fakeTicker struct {
+	*fakeWaiter
+}
+
+// NewFakeClock returns a FakeClock starting from given time.
+func NewFakeClock(now time.Time) *FakeClock {
+	c := &FakeClock{now: now}
+	c.changed = sync.NewCond(&c.Mutex)
+	return c
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.Lock()
+	defer c.Unlock()
+	return c.now
+}
+
+func (c *FakeClock) NewTimer(d time.Duration) Timer {
+	c.Lock()
+	defer c.Unlock()
+	w := &fakeWaiter{
+		clock: c,
+		until: c.now.Add(d),
+		c:     make(chan time.Time, 1),
+	}
+	c.addWaiter(w)
+	// Fire immediately for non-positive duration, the same as time.Timer.
+	c.advanceTo(c.now)
+	return fakeTimer{w}
+}
+
+func (c *FakeClock) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("non-positive interval for NewTicker")
+	}
+	c.Lock()
+	defer c.Unlock()
+	w := &fakeWaiter{
+		clock:  c,
+		until:  c.now.Add(d),
+		period: d,
+		c:      make(chan time.Time, 1),
+	}
+	c.addWaiter(w)
+	return fakeTicker{w}
+}
+
+// Advance moves the time forward, and fires all the timers and tickers due.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.Lock()
+	defer c.Unlock()
+	c.advanceTo(c.now.Add(d))
+}
+
+// Set moves the time to t, and fires all the timers and tickers due.
+// The time never goes backward.
+func (c *FakeClock) Set(t time.Time) {
+	c.Lock()
+	defer c.Unlock()
+	if t.After(c.now) {
+		c.advanceTo(t)
+	}
+}
+
+// BlockUntil blocks until there are at least n active timers and tickers.
+// It's useful to make sure the goroutine under test is waiting before advancing.
+func (c *FakeClock) BlockUntil(n int) {
+	c.Lock()
+	defer c.Unlock()
+	for len(c.waiters) < n {
+		c.changed.Wait()
+	}
+}
+
+func (c *FakeClock) addWaiter(w *fakeWaiter) {
+	c.waiters = append(c.waiters, w)
+	c.changed.Broadcast()
+}
+
+func (c *FakeClock) removeWaiter(w *fakeWaiter) bool {
+	for i, waiter := range c.waiters {
+		if waiter == w {
+			c.waiters = append(c.waiters[:i], c.waiters[i+1:]...)
+			c.changed.Broadcast()
+			return true
+		}
+	}
+	return false
+}
+
+// advanceTo fires the waiters one by one. The lock must be held by caller.
+func (c *FakeClock) advanceTo(t time.Time) {
+	for {
+		sort.SliceStable(c.waiters, func(i, j int) bool {
+			return c.waiters[i].until.Before(c.waiters[j].until)
+		})
+		if len(c.waiters) == 0 || c.waiters[0].until.After(t) {
+			break
+		}
+		w := c.waiters[0]
+		c.now = w.until
+		select {
+		case w.c <- c.now:
+		default:
+			// Drop the tick for slow receivers, the same as time.Ticker.
+		}
+		if w.period > 0 {
+			w.until = w.until.Add(w.period)
+		} else {
+			c.removeWaiter(w)
+		}
+	}
+	c.now = t
+}
+
+func (w *fakeWaiter) C() <-chan time.Time {
+	return w.c
+}
+
+func (t fakeTimer) Stop() bool {
+	t.clock.Lock()
+	defer t.clock.Unlock()
+	return t.clock.removeWaiter(t.fakeWaiter)
+}
+
+func (t fakeTimer) Reset(d time.Duration) bool {
+	t.clock.Lock()
+	defer t.clock.Unlock()
+	active := t.clock.removeWaiter(t.fakeWaiter)
+	t.until = t.clock.now.Add(d)
+	t.clock.addWaiter(t.fakeWaiter)
+	t.clock.advanceTo(t.clock.now)
+	return active
+}
+
+func (t fakeTicker) Stop() {
+	t.clock.Lock()
+	defer t.clock.Unlock()
+	t.clock.removeWaiter(t.fakeWaiter)
+}
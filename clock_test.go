@@ -0,0 +1,67 @@
+package goutils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFakeClockTimer(t *testing.T) {
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, ChinaTimezone)
+	clock := NewFakeClock(start)
+
+	timer := clock.NewTimer(time.Minute)
+	ticker := clock.NewTicker(20 * time.Second)
+	clock.Advance(30 * time.Second)
+
+	select {
+	case <-timer.C():
+		t.Error("Timer fired too early")
+	default:
+	}
+	if tick := <-ticker.C(); !tick.Equal(start.Add(20 * time.Second)) {
+		t.Error("Unexpected tick time", tick)
+	}
+
+	clock.Advance(30 * time.Second)
+	if fired := <-timer.C(); !fired.Equal(start.Add(time.Minute)) {
+		t.Error("Unexpected timer time", fired)
+	}
+	if timer.Stop() {
+		t.Error("Stop returns true for fired timer")
+	}
+	if !clock.Now().Equal(start.Add(time.Minute)) {
+		t.Error("Unexpected now", clock.Now())
+	}
+
+	ticker.Stop()
+	<-ticker.C()
+	clock.Advance(time.Minute)
+	select {
+	case <-ticker.C():
+		t.Error("Ticker not stopped")
+	default:
+	}
+}
+
+func TestCronTickerWithFakeClock(t *testing.T) {
+	clock := NewFakeClock(time.Date(2018, 1, 1, 0, 3, 0, 0, ChinaTimezone))
+	ticker := NewCronTickerWithClock(10*time.Minute, clock)
+	defer ticker.Stop()
+
+	clock.BlockUntil(1)
+	clock.Advance(7 * time.Minute)
+	<-ticker.C
+	if !clock.Now().Equal(time.Date(2018, 1, 1, 0, 10, 0, 0, ChinaTimezone)) {
+		t.Error("Unexpected tick time", clock.Now())
+	}
+}
+
+func TestSetClock(t *testing.T) {
+	now := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	old := GetClock()
+	defer SetClock(old)
+	SetClock(NewFakeClock(now))
+	if !GetNow().Equal(now) || GetNow().Location() != ChinaTimezone {
+		t.Error("GetNow not using the default clock", GetNow())
+	}
+}
@@ -0,0 +1,168 @@
+package goutils
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/hoveychen/go-utils/flags"
+	"github.com/pkg/errors"
+	"github.com/vharitonsky/iniflags"
+)
+
+// Command is a subcommand of the binary, dispatched by RunCommands().
+type Command struct {
+	Name string
+	// Short is the one-line description shown in the help.
+	Short string
+	// Flags are specific to the command, given after the command name. Global
+	// flags are accepted after the command name as well.
+	Flags *flag.FlagSet
+	Run   func(args []string) error
+}
+
+var (
+	commands = map[string]*Command{}
+)
+
+// AddCommand registers a subcommand. The command flags should be defined by
+// the Flags of the returned command.
+// Example usage:
+//   func main() {
+//       export := goutils.AddCommand("export", "Export the records updated since given time.", nil)
+//       since := export.Flags.String("since", "", "Start time of records.")
+//       export.Run = func(args []string) error {
+//           return exportRecords(*since, args)
+//       }
+//       goutils.AddCommand("sync", "Sync the collections.", syncCollections)
+//       if err := goutils.RunCommands(); err != nil {
+//           goutils.LogFatal(err)
+//       }
+//   }
+//   // $ app --debug export --since=2018-01-01 a.csv
+func AddCommand(name, short string, run func(args []string) error) *Command {
+	if _, exists := commands[name]; exists || name == "help" {
+		panic("Duplicated command " + name)
+	}
+	c := &Command{
+		Name:  name,
+		Short: short,
+		Flags: flag.NewFlagSet(name, flag.ContinueOnError),
+		Run:   run,
+	}
+	commands[name] = c
+	return c
+}
+
+// RunCommands parses the global flags and the flags of the chosen command,
+// runs the initialization like Init(), and then runs the command.
+// The help is printed for `app help [command]` or -h, and the process exits
+// for unknown commands or flags. Init() should not be called along with it.
+func RunCommands() error {
+	flag.Usage = func() {
+		printCommands(os.Stderr)
+	}
+	flag.Parse()
+	args := flag.Args()
+	if len(args) == 0 {
+		printCommands(os.Stderr)
+		os.Exit(2)
+	}
+	if args[0] == "help" {
+		if len(args) > 1 && commands[args[1]] != nil {
+			commands[args[1]].printUsage(os.Stdout)
+		} else {
+			printCommands(os.Stdout)
+		}
+		os.Exit(0)
+	}
+	c := commands[args[0]]
+	if c == nil {
+		fmt.Fprintf(os.Stderr, "Unknown command %s\n\n", args[0])
+		printCommands(os.Stderr)
+		os.Exit(2)
+	}
+	if c.Run == nil {
+		return errors.Errorf("Run of command %s is not set", c.Name)
+	}
+
+	fs, err := c.flagSet()
+	if err != nil {
+		return err
+	}
+	ran := runInit(func() error {
+		return flags.ParseCommand(iniflags.Parse, fs, args[1:])
+	})
+	if !ran {
+		return errors.New("Init() is called before RunCommands()")
+	}
+	return c.Run(fs.Args())
+}
+
+// flagSet returns the flag set of the command layered over the global flags.
+func (c *Command) flagSet() (*flag.FlagSet, error) {
+	fs := flag.NewFlagSet(c.Name, flag.ExitOnError)
+	var err error
+	c.Flags.VisitAll(func(f *flag.Flag) {
+		if flag.Lookup(f.Name) != nil {
+			err = errors.Errorf("Flag --%s of command %s conflicts with the global flag", f.Name, c.Name)
+		}
+		fs.Var(f.Value, f.Name, f.Usage)
+	})
+	if err != nil {
+		return nil, err
+	}
+	flags.AddGlobalFlags(fs)
+	fs.Usage = func() {
+		c.printUsage(os.Stderr)
+	}
+	return fs, nil
+}
+
+func (c *Command) printUsage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: %s %s [flags] [args]\n\n", programName(), c.Name)
+	if c.Short != "" {
+		fmt.Fprintf(w, "%s\n\n", c.Short)
+	}
+	hasFlags := false
+	c.Flags.VisitAll(func(*flag.Flag) {
+		hasFlags = true
+	})
+	if hasFlags {
+		fmt.Fprintln(w, "Flags:")
+		c.Flags.SetOutput(w)
+		c.Flags.PrintDefaults()
+		c.Flags.SetOutput(nil)
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintf(w, "Global flags are accepted as well, see %s help.\n", programName())
+}
+
+func printCommands(w io.Writer) {
+	fmt.Fprintf(w, "Usage: %s [global flags] <command> [flags] [args]\n\n", programName())
+	fmt.Fprintln(w, "Commands:")
+	var names []string
+	width := len("help")
+	for name := range commands {
+		names = append(names, name)
+		if len(name) > width {
+			width = len(name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-*s  %s\n", width, name, commands[name].Short)
+	}
+	fmt.Fprintf(w, "  %-*s  %s\n\n", width, "help", "Show the help of a command.")
+	fmt.Fprintln(w, "Global flags:")
+	flag.CommandLine.SetOutput(w)
+	flag.PrintDefaults()
+	flag.CommandLine.SetOutput(nil)
+}
+
+func programName() string {
+	return filepath.Base(os.Args[0])
+}
@@ -0,0 +1,76 @@
+package goutils
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestCommandFlagSet(t *testing.T) {
+	var got []string
+	c := AddCommand("testExport", "Export the records.", func(args []string) error {
+		got = args
+		return nil
+	})
+	since := c.Flags.String("testSince", "", "Start time of records.")
+
+	fs, err := c.flagSet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.Parse([]string{"--testSince=2018-01-01", "--debug", "a.csv"}); err != nil {
+		t.Fatal(err)
+	}
+	defer flag.Set("debug", "false")
+	if *since != "2018-01-01" || !IsDebuging() {
+		t.Errorf("Flags are not parsed: %s, %v", *since, IsDebuging())
+	}
+	c.Run(fs.Args())
+	if len(got) != 1 || got[0] != "a.csv" {
+		t.Errorf("Args = %v", got)
+	}
+
+	conflict := AddCommand("testConflict", "", nil)
+	conflict.Flags.Bool("debug", false, "")
+	if _, err := conflict.flagSet(); err == nil {
+		t.Error("No error for conflicted flags")
+	}
+}
+
+func TestRunCommandsWithoutRun(t *testing.T) {
+	AddCommand("testNoRun", "", nil)
+	args := os.Args
+	defer func() {
+		os.Args = args
+	}()
+	os.Args = []string{args[0], "testNoRun"}
+	if err := RunCommands(); err == nil || !strings.Contains(err.Error(), "testNoRun") {
+		t.Errorf("RunCommands() = %v", err)
+	}
+}
+
+func TestCommandHelp(t *testing.T) {
+	c := AddCommand("testSync", "Sync the collections.", nil)
+	c.Flags.Int("testBatch", 100, "Batch size.")
+
+	buf := &bytes.Buffer{}
+	printCommands(buf)
+	for _, want := range []string{"<command>", "testSync", "Sync the collections.", "help", "Global flags:", "-debug"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Commands help missing %q:\n%s", want, buf.String())
+		}
+	}
+
+	buf.Reset()
+	c.printUsage(buf)
+	for _, want := range []string{"testSync [flags]", "Sync the collections.", "-testBatch", "Batch size."} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Command help missing %q:\n%s", want, buf.String())
+		}
+	}
+	if strings.Contains(buf.String(), "-debug") {
+		t.Errorf("Command help contains global flags:\n%s", buf.String())
+	}
+}
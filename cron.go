@@ -0,0 +1,491 @@
+package goutils
+
+import (
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Schedule describes the fire times of a job.
+type Schedule interface {
+	// Next returns the first fire time strictly after t, in the location of t.
+	// Zero time is returned if no such time exists.
+	Next(t time.Time) time.Time
+}
+
+// CronSchedule is a schedule parsed from standard cron expression.
+type CronSchedule struct {
+	second, minute, hour, dom, month, dow uint64
+	// Day of month and day of week are OR-ed if both are restricted.
+	domStar, dowStar bool
+}
+
+// IntervalSchedule fires every interval, aligned to the midnight of the
+// location. For example, an interval of 10 minutes fires at xx:00:00, xx:10:00,
+// etc. Intervals not dividing a day restart at every midnight, and intervals
+// longer than a day are not aligned at all.
+type IntervalSchedule struct {
+	Interval time.Duration
+}
+
+type cronField struct {
+	min, max int
+	names    map[string]int
+}
+
+var (
+	secondField = cronField{0, 59, nil}
+	minuteField = cronField{0, 59, nil}
+	hourField   = cronField{0, 23, nil}
+	domField    = cronField{1, 31, nil}
+	monthField  = cronField{1, 12, map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// Both 0 and 7 are Sunday.
+	dowField = cronField{0, 7, map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+
+	cronDescriptors = map[string]string{
+		"@yearly":   "0 0 0 1 1 *",
+		"@annually": "0 0 0 1 1 *",
+		"@monthly":  "0 0 0 1 * *",
+		"@weekly":   "0 0 0 * * 0",
+		"@daily":    "0 0 0 * * *",
+		"@midnight": "0 0 0 * * *",
+		"@hourly":   "0 0 * * * *",
+	}
+)
+
+// ParseSchedule parses the cron expression into schedule. It accepts:
+//   5 fields: minute hour day-of-month month day-of-week
+//   6 fields: second minute hour day-of-month month day-of-week
+//   descriptors: @yearly, @annually, @monthly, @weekly, @daily, @midnight, @hourly
+//   @every <duration>: e.g. @every 10m, see IntervalSchedule
+// Each field supports *, ?, lists(1,3), ranges(1-5), steps(*/10, 1-30/5) and
+// names of month and day of week(JAN, MON).
+func ParseSchedule(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if strings.HasPrefix(spec, "@every ") {
+		d, err := time.ParseDuration(strings.TrimSpace(spec[len("@every "):]))
+		if err != nil {
+			return nil, errors.Wrapf(err, "Parse interval: %s", spec)
+		}
+		if d <= 0 {
+			return nil, errors.Errorf("Non-positive interval: %s", spec)
+		}
+		return &IntervalSchedule{Interval: d}, nil
+	}
+	if strings.HasPrefix(spec, "@") {
+		expr, ok := cronDescriptors[strings.ToLower(spec)]
+		if !ok {
+			return nil, errors.Errorf("Unknown descriptor: %s", spec)
+		}
+		spec = expr
+	}
+
+	fields := strings.Fields(spec)
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, errors.Errorf("Expect 5 or 6 fields, got %d: %s", len(fields), spec)
+	}
+
+	s := &CronSchedule{}
+	var err error
+	for i, f := range []struct {
+		expr  string
+		field cronField
+		bits  *uint64
+	}{
+		{fields[0], secondField, &s.second},
+		{fields[1], minuteField, &s.minute},
+		{fields[2], hourField, &s.hour},
+		{fields[3], domField, &s.dom},
+		{fields[4], monthField, &s.month},
+		{fields[5], dowField, &s.dow},
+	} {
+		*f.bits, err = f.field.parse(f.expr)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Field %d of %s", i+1, spec)
+		}
+	}
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.domStar = fields[3] == "*" || fields[3] == "?"
+	s.dowStar = fields[5] == "*" || fields[5] == "?"
+	return s, nil
+}
+
+// MustParseSchedule is the same as ParseSchedule(), except it panics on error.
+func MustParseSchedule(spec string) Schedule {
+	s, err := ParseSchedule(spec)
+	Check(err)
+	return s
+}
+
+func (f cronField) value(s string) (int, error) {
+	if v, ok := f.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, errors.Errorf("Invalid value %s", s)
+	}
+	if v < f.min || v > f.max {
+		return 0, errors.Errorf("Value %d out of range [%d, %d]", v, f.min, f.max)
+	}
+	return v, nil
+}
+
+func (f cronField) parse(expr string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(expr, ",") {
+		rangeExpr, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			rangeExpr = part[:i]
+			step, err = strconv.Atoi(part[i+1:])
+			if err != nil || step <= 0 {
+				return 0, errors.Errorf("Invalid step %s", part)
+			}
+		}
+
+		var start, end int
+		switch {
+		case rangeExpr == "*" || rangeExpr == "?":
+			start, end = f.min, f.max
+		case strings.Contains(rangeExpr, "-"):
+			segs := strings.SplitN(rangeExpr, "-", 2)
+			var err error
+			if start, err = f.value(segs[0]); err != nil {
+				return 0, err
+			}
+			if end, err = f.value(segs[1]); err != nil {
+				return 0, err
+			}
+			if start > end {
+				return 0, errors.Errorf("Invalid range %s", rangeExpr)
+			}
+		default:
+			var err error
+			if start, err = f.value(rangeExpr); err != nil {
+				return 0, err
+			}
+			end = start
+			if step > 1 {
+				// a/n is the same as a-max/n
+				end = f.max
+			}
+		}
+		for v := start; v <= end; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func (s *CronSchedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// Next implements the Schedule interface.
+func (s *CronSchedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Second).Add(time.Second)
+	yearLimit := t.Year() + 5
+
+WRAP:
+	if t.Year() > yearLimit {
+		return time.Time{}
+	}
+	for s.month&(1<<uint(t.Month())) == 0 {
+		t = midnight(t.Year(), t.Month()+1, 1, loc)
+		if t.Month() == time.January {
+			goto WRAP
+		}
+	}
+	for !s.dayMatches(t) {
+		t = midnight(t.Year(), t.Month(), t.Day()+1, loc)
+		if t.Day() == 1 {
+			goto WRAP
+		}
+	}
+	// Steps forward by the absolute time, as the wall clock may repeat or skip
+	// around the daylight saving time transitions. Rechecks from the top once
+	// the larger unit changes.
+	for s.hour&(1<<uint(t.Hour())) == 0 {
+		day := t.Day()
+		t = t.Add(time.Hour - time.Duration(t.Minute())*time.Minute - time.Duration(t.Second())*time.Second - time.Duration(t.Nanosecond()))
+		if t.Day() != day {
+			goto WRAP
+		}
+	}
+	for s.minute&(1<<uint(t.Minute())) == 0 {
+		hour := t.Hour()
+		t = t.Truncate(time.Minute).Add(time.Minute)
+		if t.Hour() != hour {
+			goto WRAP
+		}
+	}
+	for s.second&(1<<uint(t.Second())) == 0 {
+		minute := t.Minute()
+		t = t.Truncate(time.Second).Add(time.Second)
+		if t.Minute() != minute {
+			goto WRAP
+		}
+	}
+	return t
+}
+
+// Next implements the Schedule interface.
+func (s *IntervalSchedule) Next(t time.Time) time.Time {
+	if s.Interval >= 24*time.Hour {
+		return t.Add(s.Interval)
+	}
+	start := midnight(t.Year(), t.Month(), t.Day(), t.Location())
+	nextMidnight := midnight(t.Year(), t.Month(), t.Day()+1, t.Location())
+	next := start.Add((t.Sub(start)/s.Interval + 1) * s.Interval)
+	if next.After(nextMidnight) {
+		return nextMidnight
+	}
+	return next
+}
+
+// OverlapPolicy determines what to do when a job is due while its previous run
+// is still running.
+type OverlapPolicy int
+
+const (
+	// OverlapAllow runs the job concurrently.
+	OverlapAllow OverlapPolicy = iota
+	// OverlapSkip skips the due run.
+	OverlapSkip
+	// OverlapQueue runs the job after the previous runs finish.
+	OverlapQueue
+)
+
+// Scheduler runs jobs periodically by cron expressions.
+// Example usage:
+//   s := NewScheduler()
+//   s.Add("0 3 * * *", backup, WithJobOverlap(OverlapSkip))
+//   s.Add("@every 10m", poll, WithJobJitter(time.Second*30))
+//   s.Start()
+//   defer s.Stop()
+type Scheduler struct {
+	sync.Mutex
+	loc     *time.Location
+	clock   Clock
+	jobs    []*cronJob
+	stop    chan struct{}
+	wg      sync.WaitGroup
+	running bool
+}
+
+type SchedulerOption func(*Scheduler)
+
+// WithSchedulerLocation sets the location to compute fire times. The configured
+// timezone by default, which is resolved at every fire time, so it's fine to
+// create the scheduler before Init().
+func WithSchedulerLocation(loc *time.Location) SchedulerOption {
+	return func(s *Scheduler) {
+		s.loc = loc
+	}
+}
+
+// WithSchedulerClock sets the clock to schedule the jobs.
+func WithSchedulerClock(clock Clock) SchedulerOption {
+	return func(s *Scheduler) {
+		s.clock = clock
+	}
+}
+
+type cronJob struct {
+	schedule Schedule
+	fn       func()
+	overlap  OverlapPolicy
+	jitter   time.Duration
+	catchUp  bool
+
+	runLock sync.Mutex
+	active  int32
+}
+
+type JobOption func(*cronJob)
+
+// WithJobOverlap sets the policy of overlapping runs. OverlapAllow by default.
+func WithJobOverlap(policy OverlapPolicy) JobOption {
+	return func(j *cronJob) {
+		j.overlap = policy
+	}
+}
+
+// WithJobJitter delays every run by a random duration in [0, jitter), in case
+// many instances hit the same resource at the same time.
+func WithJobJitter(jitter time.Duration) JobOption {
+	return func(j *cronJob) {
+		j.jitter = jitter
+	}
+}
+
+// WithJobCatchUp sets whether to run the missed fire times, e.g. when the
+// process is suspended, instead of skipping to the next fire time.
+func WithJobCatchUp(catchUp bool) JobOption {
+	return func(j *cronJob) {
+		j.catchUp = catchUp
+	}
+}
+
+func NewScheduler(opts ...SchedulerOption) *Scheduler {
+	s := &Scheduler{
+		clock: GetClock(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Add registers a job by cron expression. See ParseSchedule() for the syntax.
+func (s *Scheduler) Add(spec string, fn func(), opts ...JobOption) error {
+	schedule, err := ParseSchedule(spec)
+	if err != nil {
+		return err
+	}
+	s.AddSchedule(schedule, fn, opts...)
+	return nil
+}
+
+// AddSchedule registers a job by any schedule.
+func (s *Scheduler) AddSchedule(schedule Schedule, fn func(), opts ...JobOption) {
+	j := &cronJob{
+		schedule: schedule,
+		fn:       fn,
+	}
+	for _, opt := range opts {
+		opt(j)
+	}
+
+	s.Lock()
+	defer s.Unlock()
+	s.jobs = append(s.jobs, j)
+	if s.running {
+		s.startJob(j)
+	}
+}
+
+// Start begins to schedule the jobs in background.
+func (s *Scheduler) Start() {
+	s.Lock()
+	defer s.Unlock()
+	if s.running {
+		return
+	}
+	s.running = true
+	s.stop = make(chan struct{})
+	for _, j := range s.jobs {
+		s.startJob(j)
+	}
+}
+
+// Stop stops scheduling, and blocks until all the running jobs finish.
+func (s *Scheduler) Stop() {
+	s.Lock()
+	if !s.running {
+		s.Unlock()
+		return
+	}
+	s.running = false
+	close(s.stop)
+	s.Unlock()
+
+	s.wg.Wait()
+}
+
+func (s *Scheduler) startJob(j *cronJob) {
+	s.wg.Add(1)
+	go func(stop <-chan struct{}) {
+		defer s.wg.Done()
+		s.runJob(j, stop)
+	}(s.stop)
+}
+
+func (s *Scheduler) location() *time.Location {
+	if s.loc != nil {
+		return s.loc
+	}
+	return GetTimezone()
+}
+
+func (s *Scheduler) runJob(j *cronJob, stop <-chan struct{}) {
+	last := s.clock.Now().In(s.location())
+	for {
+		next := j.schedule.Next(last)
+		if next.IsZero() {
+			return
+		}
+		delay := next.Sub(s.clock.Now())
+		if j.jitter > 0 {
+			delay += time.Duration(rand.Int63n(int64(j.jitter)))
+		}
+		timer := s.clock.NewTimer(delay)
+		select {
+		case <-stop:
+			timer.Stop()
+			return
+		case <-timer.C():
+		}
+
+		s.fire(j)
+
+		last = next.In(s.location())
+		if now := s.clock.Now().In(s.location()); !j.catchUp && now.After(last) {
+			last = now
+		}
+	}
+}
+
+func (s *Scheduler) fire(j *cronJob) {
+	switch j.overlap {
+	case OverlapSkip:
+		if !atomic.CompareAndSwapInt32(&j.active, 0, 1) {
+			LogDebug("Skip overlapping run of job", GetFuncName(j.fn))
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer atomic.StoreInt32(&j.active, 0)
+			j.fn()
+		}()
+	case OverlapQueue:
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			// Every run is identical, so the order of waiting doesn't matter.
+			j.runLock.Lock()
+			defer j.runLock.Unlock()
+			j.fn()
+		}()
+	default:
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			j.fn()
+		}()
+	}
+}
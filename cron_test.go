@@ -0,0 +1,179 @@
+package goutils
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseScheduleNext(t *testing.T) {
+	base := time.Date(2018, 3, 4, 16, 5, 30, 0, ChinaTimezone) // Sunday
+	cases := []struct {
+		Spec   string
+		Expect []time.Time
+	}{
+		{"*/15 * * * *", []time.Time{
+			time.Date(2018, 3, 4, 16, 15, 0, 0, ChinaTimezone),
+			time.Date(2018, 3, 4, 16, 30, 0, 0, ChinaTimezone),
+		}},
+		{"30 */20 * * * *", []time.Time{
+			time.Date(2018, 3, 4, 16, 20, 30, 0, ChinaTimezone),
+			time.Date(2018, 3, 4, 16, 40, 30, 0, ChinaTimezone),
+		}},
+		{"0 9 * * MON-FRI", []time.Time{
+			time.Date(2018, 3, 5, 9, 0, 0, 0, ChinaTimezone),
+			time.Date(2018, 3, 6, 9, 0, 0, 0, ChinaTimezone),
+		}},
+		{"0 0 1,15 * 0", []time.Time{
+			time.Date(2018, 3, 11, 0, 0, 0, 0, ChinaTimezone),
+			time.Date(2018, 3, 15, 0, 0, 0, 0, ChinaTimezone),
+		}},
+		{"0 0 29 2 *", []time.Time{
+			time.Date(2020, 2, 29, 0, 0, 0, 0, ChinaTimezone),
+			time.Date(2024, 2, 29, 0, 0, 0, 0, ChinaTimezone),
+		}},
+		{"@daily", []time.Time{
+			time.Date(2018, 3, 5, 0, 0, 0, 0, ChinaTimezone),
+			time.Date(2018, 3, 6, 0, 0, 0, 0, ChinaTimezone),
+		}},
+		{"@monthly", []time.Time{
+			time.Date(2018, 4, 1, 0, 0, 0, 0, ChinaTimezone),
+			time.Date(2018, 5, 1, 0, 0, 0, 0, ChinaTimezone),
+		}},
+		{"@every 7h", []time.Time{
+			time.Date(2018, 3, 4, 21, 0, 0, 0, ChinaTimezone),
+			time.Date(2018, 3, 5, 0, 0, 0, 0, ChinaTimezone),
+		}},
+	}
+
+	for _, c := range cases {
+		s, err := ParseSchedule(c.Spec)
+		if err != nil {
+			t.Errorf("%s: %v", c.Spec, err)
+			continue
+		}
+		next := base
+		for _, expect := range c.Expect {
+			next = s.Next(next)
+			if !next.Equal(expect) {
+				t.Errorf("%s: Expect %v, Actual %v", c.Spec, expect, next)
+				break
+			}
+		}
+	}
+}
+
+func TestParseScheduleError(t *testing.T) {
+	for _, spec := range []string{"", "* * *", "60 * * * *", "* * * 13 *", "*/0 * * * *", "5-1 * * * *", "@sometimes", "@every -1s"} {
+		if _, err := ParseSchedule(spec); err == nil {
+			t.Errorf("Expect error parsing %q", spec)
+		}
+	}
+}
+
+func TestScheduleLocation(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("No tzdata", err)
+	}
+	s := MustParseSchedule("30 2 * * *")
+	// 2:30 doesn't exist on the day DST starts.
+	next := s.Next(time.Date(2018, 3, 10, 12, 0, 0, 0, ny))
+	if !next.Equal(time.Date(2018, 3, 12, 2, 30, 0, 0, ny)) {
+		t.Errorf("Unexpected next time: %v", next)
+	}
+}
+
+func TestScheduleDST(t *testing.T) {
+	utc := func(year int, month time.Month, day, hour, min int) time.Time {
+		return time.Date(year, month, day, hour, min, 0, 0, time.UTC)
+	}
+	cases := []struct {
+		Zone   string
+		Spec   string
+		From   time.Time
+		Expect time.Time
+	}{
+		// 2024-11-03 01:00-02:00 repeats in New York.
+		{"America/New_York", "0 0 5 * * *", utc(2024, 11, 3, 6, 30), utc(2024, 11, 3, 10, 0)},
+		{"America/New_York", "0 0 * * * *", utc(2024, 11, 3, 5, 50), utc(2024, 11, 3, 6, 0)},
+		// 2024-03-10 02:00-03:00 is skipped in New York.
+		{"America/New_York", "0 30 2 * * *", utc(2024, 3, 10, 5, 0), utc(2024, 3, 11, 6, 30)},
+		{"America/New_York", "0 0 * * * *", utc(2024, 3, 10, 6, 30), utc(2024, 3, 10, 7, 0)},
+		// 2024-04-07 02:00-03:00 repeats in Adelaide, +10:30 then +09:30.
+		{"Australia/Adelaide", "0 0 4 * * *", utc(2024, 4, 6, 17, 0), utc(2024, 4, 6, 18, 30)},
+		{"Australia/Adelaide", "0 0 * * * *", utc(2024, 4, 6, 16, 0), utc(2024, 4, 6, 16, 30)},
+		// 2024-10-06 02:00-03:00 is skipped in Adelaide, +09:30 then +10:30.
+		{"Australia/Adelaide", "0 30 2 * * *", utc(2024, 10, 5, 14, 30), utc(2024, 10, 6, 16, 0)},
+		{"Australia/Adelaide", "0 0 * * * *", utc(2024, 10, 5, 16, 0), utc(2024, 10, 5, 16, 30)},
+	}
+	for _, c := range cases {
+		loc, err := time.LoadLocation(c.Zone)
+		if err != nil {
+			t.Fatal(err)
+		}
+		next := MustParseSchedule(c.Spec).Next(c.From.In(loc))
+		if !next.Equal(c.Expect) {
+			t.Errorf("%s %s from %v: Expect %v, Actual %v", c.Zone, c.Spec, c.From.In(loc), c.Expect.In(loc), next)
+		}
+	}
+}
+
+func TestSchedulerOverlapSkip(t *testing.T) {
+	var runs int32
+	s := NewScheduler()
+	s.AddSchedule(&IntervalSchedule{Interval: 20 * time.Millisecond}, func() {
+		atomic.AddInt32(&runs, 1)
+		time.Sleep(100 * time.Millisecond)
+	}, WithJobOverlap(OverlapSkip))
+	s.Start()
+	time.Sleep(150 * time.Millisecond)
+	s.Stop()
+
+	if n := atomic.LoadInt32(&runs); n < 1 || n > 2 {
+		t.Errorf("Unexpected runs: %d", n)
+	}
+}
+
+func TestCronTickerStop(t *testing.T) {
+	ticker := NewCronTicker(10 * time.Millisecond)
+	<-ticker.C
+	ticker.Stop()
+	ticker.Stop()
+	select {
+	case <-ticker.C:
+		// A tick may be sent right before stopping.
+	case <-time.After(50 * time.Millisecond):
+	}
+	select {
+	case <-ticker.C:
+		t.Error("Ticker not stopped")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestSchedulerDefaultLocation(t *testing.T) {
+	old := GetTimezone()
+	defer SetTimezone(old)
+
+	// 08:00 in +8, and 00:00 in UTC.
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+	s := NewScheduler(WithSchedulerClock(clock))
+	// The timezone configured after creating the scheduler, e.g. by Init().
+	SetTimezone(time.UTC)
+
+	fired := make(chan struct{}, 1)
+	s.Add("0 3 * * *", func() {
+		fired <- struct{}{}
+	})
+	s.Start()
+	defer s.Stop()
+	clock.BlockUntil(1)
+	clock.Advance(3 * time.Hour)
+	select {
+	case <-fired:
+	case <-time.After(time.Second):
+		t.Error("Job not fired at 03:00 in the configured timezone")
+	}
+}
@@ -0,0 +1,237 @@
+package flags
+
+import (
+	"flag"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var (
+	required []string
+
+	timeType = reflect.TypeOf(time.Time{})
+	urlType  = reflect.TypeOf(&url.URL{})
+)
+
+// Bind registers flags from the exported fields of the struct pointed by ptr.
+// The flag name is given by the `flag` tag, or the lower camel case of the
+// field name. `default` and `usage` tags are the same as the arguments of
+// String(), Int(), etc. The current field value is used if no default given.
+// Fields with `required:"true"` are validated by ValidateNonZero() in Parse(),
+// and fields with `reloadable:"true"` are marked by Reloadable().
+// String fields may be tagged `enum:"a|b"` or `secret:"true"` to work as Enum()
+// and Secret().
+// Nested structs are bound with the prefix of their names, and embedded
+// structs are bound without prefix. Use `flag:"-"` to skip a field.
+// Example usage:
+//   type Config struct {
+//       Addr    string        `flag:"addr" default:":8080" usage:"Address to listen."`
+//       Timeout time.Duration `default:"10s" usage:"Timeout of requests."`
+//       Mongo   struct {
+//           Router string `usage:"Router config file." required:"true"`
+//       }
+//   }
+//   var cfg Config
+//   func init() {
+//       if err := flags.Bind(&cfg); err != nil {
+//           panic(err)
+//       }
+//   }
+//   // Flags --addr, --timeout and --mongo.router are registered.
+func Bind(ptr interface{}) error {
+	val := reflect.ValueOf(ptr)
+	if val.Kind() != reflect.Ptr || val.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("Input need to be a ptr to struct")
+	}
+	return bindStruct(val.Elem(), "")
+}
+
+func bindStruct(val reflect.Value, prefix string) error {
+	typ := val.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.PkgPath != "" {
+			// Unexported field will have PkgPath.
+			continue
+		}
+		tag := field.Tag.Get("flag")
+		if tag == "-" {
+			continue
+		}
+		name := tag
+		if name == "" {
+			name = lowerCamel(field.Name)
+		}
+		fv := val.Field(i)
+
+		// Values in struct form, which are not namespaces.
+		leaf := field.Type == timeType || field.Type == urlType
+		if !leaf && field.Type.Kind() == reflect.Ptr && field.Type.Elem().Kind() == reflect.Struct {
+			if fv.IsNil() {
+				fv.Set(reflect.New(field.Type.Elem()))
+			}
+			fv = fv.Elem()
+		}
+		if !leaf && fv.Kind() == reflect.Struct {
+			nested := prefix + name + "."
+			if field.Anonymous && tag == "" {
+				nested = prefix
+			}
+			if err := bindStruct(fv, nested); err != nil {
+				return err
+			}
+			continue
+		}
+
+		name = prefix + name
+		if err := bindField(fv, name, field.Tag); err != nil {
+			return fmt.Errorf("Bind %s to --%s: %v", field.Name, name, err)
+		}
+	}
+	return nil
+}
+
+func bindField(fv reflect.Value, name string, tag reflect.StructTag) error {
+	if _, exists := ptrs[name]; exists {
+		return fmt.Errorf("Flag already defined")
+	}
+	usage := tag.Get("usage")
+	def, hasDefault := tag.Lookup("default")
+
+	var err error
+	var register func()
+	var value flag.Value
+	switch ptr := fv.Addr().Interface().(type) {
+	case *string:
+		if enum := tag.Get("enum"); enum != "" {
+			allowed := strings.Split(enum, "|")
+			value = &enumValue{ptr, allowed}
+			usage = fmt.Sprintf("%s One of %s.", usage, enum)
+			break
+		}
+		if tag.Get("secret") == "true" {
+			value = &secretValue{ptr}
+			break
+		}
+		if hasDefault {
+			*ptr = def
+		}
+		register = func() { flag.StringVar(ptr, name, *ptr, usage) }
+	case *bool:
+		if hasDefault {
+			*ptr, err = strconv.ParseBool(def)
+		}
+		register = func() { flag.BoolVar(ptr, name, *ptr, usage) }
+	case *int:
+		if hasDefault {
+			*ptr, err = strconv.Atoi(def)
+		}
+		register = func() { flag.IntVar(ptr, name, *ptr, usage) }
+	case *int64:
+		if hasDefault {
+			*ptr, err = strconv.ParseInt(def, 10, 64)
+		}
+		register = func() { flag.Int64Var(ptr, name, *ptr, usage) }
+	case *uint:
+		if hasDefault {
+			var n uint64
+			n, err = strconv.ParseUint(def, 10, 0)
+			*ptr = uint(n)
+		}
+		register = func() { flag.UintVar(ptr, name, *ptr, usage) }
+	case *uint64:
+		if hasDefault {
+			*ptr, err = strconv.ParseUint(def, 10, 64)
+		}
+		register = func() { flag.Uint64Var(ptr, name, *ptr, usage) }
+	case *float64:
+		if hasDefault {
+			*ptr, err = strconv.ParseFloat(def, 64)
+		}
+		register = func() { flag.Float64Var(ptr, name, *ptr, usage) }
+	case *time.Duration:
+		if hasDefault {
+			*ptr, err = time.ParseDuration(def)
+		}
+		register = func() { flag.DurationVar(ptr, name, *ptr, usage) }
+	case *[]string:
+		value = &boundSliceValue{ptr}
+	case *[]int:
+		value = &intSliceValue{ptr}
+	case *[]time.Duration:
+		value = &durationSliceValue{ptr}
+	case *map[string]string:
+		value = &mapValue{ptr}
+	case *time.Time:
+		value = &timeValue{ptr}
+	case **url.URL:
+		value = &urlValue{ptr}
+	default:
+		return fmt.Errorf("Unsupported type %s", fv.Type())
+	}
+	if value != nil {
+		if hasDefault {
+			err = value.Set(def)
+		}
+		register = func() { flag.Var(value, name, usage) }
+	}
+	if err != nil {
+		return fmt.Errorf("Parse default %q: %v", def, err)
+	}
+	register()
+	ptrs[name] = fv.Addr().Interface()
+
+	if tag.Get("required") == "true" {
+		required = append(required, name)
+	}
+	if tag.Get("reloadable") == "true" {
+		Reloadable(name)
+	}
+	return nil
+}
+
+// boundSliceValue is the same as sliceValue, except the slice lives in a
+// struct field.
+type boundSliceValue struct {
+	s *[]string
+}
+
+func (b *boundSliceValue) String() string {
+	if b == nil || b.s == nil {
+		return ""
+	}
+	return strings.Join(*b.s, ",")
+}
+
+func (b *boundSliceValue) Set(value string) error {
+	if value == "" {
+		*b.s = nil
+	} else {
+		*b.s = strings.Split(value, ",")
+	}
+	return nil
+}
+
+// lowerCamel converts the field name to the flag name, e.g. Timeout to
+// timeout, HTTPPort to httpPort and URL to url.
+func lowerCamel(name string) string {
+	runes := []rune(name)
+	for i := 0; i < len(runes) && unicode.IsUpper(runes[i]); i++ {
+		if i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+			// Keep the first letter of next word, e.g. P in HTTPPort.
+			break
+		}
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	return string(runes)
+}
+
+// validateRequired checks all the required fields bound by Bind().
+func validateRequired() error {
+	return ValidateNonZero(required...)
+}
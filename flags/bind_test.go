@@ -0,0 +1,117 @@
+package flags
+
+import (
+	"flag"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type bindTestMongo struct {
+	Router      string `usage:"Router config." required:"true"`
+	Concurrency int    `default:"10"`
+}
+
+type BindTestCommon struct {
+	Verbose bool `flag:"bindVerbose"`
+}
+
+type bindTestConfig struct {
+	BindTestCommon
+	Addr    string        `flag:"bindAddr" default:":8080" usage:"Address to listen."`
+	Timeout time.Duration `flag:"bindTimeout" default:"10s"`
+	Ratio   float64       `flag:"bindRatio"`
+	Hosts   []string      `flag:"bindHosts" default:"a,b"`
+	Skipped string        `flag:"-"`
+	Mongo   bindTestMongo `flag:"bindMongo"`
+	Redis   *struct {
+		HTTPAddr string
+	} `flag:"bindRedis"`
+	internal int
+}
+
+func TestBind(t *testing.T) {
+	cfg := &bindTestConfig{Ratio: 0.5}
+	if err := Bind(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Addr != ":8080" || cfg.Timeout != time.Second*10 || cfg.Ratio != 0.5 ||
+		!reflect.DeepEqual(cfg.Hosts, []string{"a", "b"}) || cfg.Mongo.Concurrency != 10 {
+		t.Errorf("Wrong defaults: %+v", cfg)
+	}
+	for _, name := range []string{"bindVerbose", "bindAddr", "bindTimeout", "bindRatio", "bindHosts",
+		"bindMongo.router", "bindMongo.concurrency", "bindRedis.httpAddr"} {
+		if flag.Lookup(name) == nil {
+			t.Errorf("Flag %s is not registered", name)
+		}
+	}
+	if flag.Lookup("skipped") != nil || flag.Lookup("internal") != nil {
+		t.Error("Skipped fields are registered")
+	}
+
+	flag.Set("bindTimeout", "1m")
+	flag.Set("bindHosts", "c")
+	flag.Set("bindRedis.httpAddr", "localhost")
+	if cfg.Timeout != time.Minute || !reflect.DeepEqual(cfg.Hosts, []string{"c"}) || cfg.Redis.HTTPAddr != "localhost" {
+		t.Errorf("Fields are not updated: %+v", cfg)
+	}
+	if ptr := Duration("bindTimeout", 0, ""); *ptr != time.Minute {
+		t.Errorf("Duration(bindTimeout) = %v", *ptr)
+	}
+
+	if err := validateRequired(); err == nil {
+		t.Error("No error for missing required flag")
+	}
+	flag.Set("bindMongo.router", "router.json")
+	if err := validateRequired(); err != nil {
+		t.Error(err)
+	}
+
+	if err := Bind(cfg); err == nil {
+		t.Error("No error for duplicated flags")
+	}
+	if err := Bind(&struct {
+		C chan int `flag:"bindChan"`
+	}{}); err == nil {
+		t.Error("No error for unsupported type")
+	}
+	if err := Bind(&struct {
+		N int `flag:"bindInvalid" default:"ten"`
+	}{}); err == nil || flag.Lookup("bindInvalid") != nil {
+		t.Errorf("Invalid default: %v", err)
+	}
+}
+
+func TestLowerCamel(t *testing.T) {
+	cases := map[string]string{
+		"Timeout":  "timeout",
+		"HTTPPort": "httpPort",
+		"URL":      "url",
+		"DbRouter": "dbRouter",
+		"ID2":      "id2",
+	}
+	for name, want := range cases {
+		if got := lowerCamel(name); got != want {
+			t.Errorf("lowerCamel(%s) = %s, want %s", name, got, want)
+		}
+	}
+}
+
+func TestValidateNonZero(t *testing.T) {
+	Duration("validateDuration", 0, "")
+	if err := ValidateNonZero("validateDuration"); err == nil {
+		t.Error("No error for zero duration")
+	}
+	flag.Set("validateDuration", "1s")
+	if err := ValidateNonZero("validateDuration"); err != nil {
+		t.Error(err)
+	}
+
+	ReadFile("validateFile", "", "")
+	if err := ValidateNonZero("validateFile"); err == nil {
+		t.Error("No error for missing file")
+	}
+	if err := ValidateNonZero("validateUndefined"); err == nil {
+		t.Error("No error for undefined flag")
+	}
+}
@@ -0,0 +1,200 @@
+package flags
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	configFiles = Slice("configFiles", nil, "Comma-separated YAML, JSON or TOML config files or URLs, applied in order so that later ones override the earlier, e.g. base.yaml,prod.yaml.")
+
+	fetchConfig = func(uri string) ([]byte, error) {
+		return ioutil.ReadFile(uri)
+	}
+)
+
+// SetConfigFetcher sets the function to read config files, e.g.
+// goutils.FetchData to support remote config servers. Local files are read by
+// default.
+func SetConfigFetcher(fetch func(uri string) ([]byte, error)) {
+	fetchConfig = fetch
+}
+
+// LoadConfig applies the config files to the flags in order, so that later
+// ones override the earlier. Flags given by command line or environment
+// variables are left untouched.
+// The format is determined by the extension: .json, .toml, or .yaml/.yml.
+// Sources without extension are parsed as YAML, which also accepts JSON.
+// Nested objects are flattened to dotted names, the same as the nested structs
+// in Bind(), and arrays are joined by comma. Keys without a matching flag are
+// regarded as errors. Either all the values are applied, or none of them if
+// any is invalid.
+// Example config:
+//   requestTimeout: 30
+//   mongo:
+//     router: /etc/router.json
+//   hosts: [a, b]
+func LoadConfig(uris ...string) error {
+	values, err := readConfig(uris...)
+	if err != nil {
+		return err
+	}
+	var names []string
+	for name := range values {
+		if GetSource(name) <= SourceConfig {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	// The values are validated by setting them in turn, and the ones already
+	// set are restored once any of them fails.
+	valueLock.Lock()
+	defer valueLock.Unlock()
+	olds := make([]string, 0, len(names))
+	for _, name := range names {
+		f := flag.Lookup(name)
+		olds = append(olds, rawValue(f))
+		if err := f.Value.Set(values[name]); err != nil {
+			for i := len(olds) - 1; i >= 0; i-- {
+				// Some values are reset even if failed, e.g. ints.
+				flag.Lookup(names[i]).Value.Set(olds[i])
+			}
+			return fmt.Errorf("Parse config %s: %v", name, err)
+		}
+	}
+	for _, name := range names {
+		setSource(name, SourceConfig)
+	}
+	return nil
+}
+
+// rawValue returns the flag value in string, which can be set back to the
+// flag. Unlike String(), secrets are not masked.
+func rawValue(f *flag.Flag) string {
+	if getter, ok := f.Value.(flag.Getter); ok {
+		if s, ok := getter.Get().(string); ok {
+			return s
+		}
+	}
+	return f.Value.String()
+}
+
+// readConfig returns the merged values of flags from the config files.
+func readConfig(uris ...string) (map[string]string, error) {
+	values := map[string]string{}
+	for _, uri := range uris {
+		data, err := fetchConfig(uri)
+		if err != nil {
+			return nil, fmt.Errorf("Fetch config %s: %v", uri, err)
+		}
+		m, err := decodeConfig(uri, data)
+		if err != nil {
+			return nil, fmt.Errorf("Decode config %s: %v", uri, err)
+		}
+		if err := flattenConfig("", m, values); err != nil {
+			return nil, fmt.Errorf("Decode config %s: %v", uri, err)
+		}
+	}
+
+	var unknown []string
+	for name := range values {
+		if flag.Lookup(name) == nil {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("Unknown keys in config: %s", strings.Join(unknown, ", "))
+	}
+	return values, nil
+}
+
+func decodeConfig(uri string, data []byte) (map[string]interface{}, error) {
+	ext := path.Ext(uri)
+	if u, err := url.Parse(uri); err == nil {
+		ext = path.Ext(u.Path)
+	}
+
+	m := map[string]interface{}{}
+	var err error
+	switch strings.ToLower(ext) {
+	case ".json":
+		// Numbers are kept as they are, otherwise large ints are decoded as
+		// float64 and formatted like 1e+06.
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		err = decoder.Decode(&m)
+	case ".toml":
+		err = toml.Unmarshal(data, &m)
+	case ".yaml", ".yml", "":
+		err = yaml.Unmarshal(data, &m)
+	default:
+		return nil, fmt.Errorf("Unknown config format %s", ext)
+	}
+	return m, err
+}
+
+func flattenConfig(prefix string, m map[string]interface{}, values map[string]string) error {
+	for key, val := range m {
+		name := prefix + key
+		switch v := val.(type) {
+		case map[string]interface{}:
+			if err := flattenConfig(name+".", v, values); err != nil {
+				return err
+			}
+		case []interface{}:
+			items := make([]string, len(v))
+			for i, item := range v {
+				s, err := configString(item)
+				if err != nil {
+					return fmt.Errorf("%s: %v", name, err)
+				}
+				items[i] = s
+			}
+			values[name] = strings.Join(items, ",")
+		default:
+			s, err := configString(v)
+			if err != nil {
+				return fmt.Errorf("%s: %v", name, err)
+			}
+			values[name] = s
+		}
+	}
+	return nil
+}
+
+func configString(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	case float64:
+		// Never in exponent form, so that integral floats are accepted by
+		// int flags, e.g. 1e6 in YAML.
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool, int, int64, uint64:
+		return fmt.Sprint(v), nil
+	case fmt.Stringer:
+		// e.g. local date time of TOML.
+		return v.String(), nil
+	}
+	return "", fmt.Errorf("Unsupported value %v", v)
+}
@@ -0,0 +1,118 @@
+package flags
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, dir, name, content string) string {
+	filename := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(filename, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var cfg struct {
+		Addr    string        `flag:"configAddr"`
+		Timeout time.Duration `flag:"configTimeout"`
+		Ratio   float64       `flag:"configRatio"`
+		Hosts   []string      `flag:"configHosts"`
+		Mongo   struct {
+			Router string
+			Limit  int
+		} `flag:"configMongo"`
+		Cmdline string `flag:"configCmdline" default:"cmdline"`
+	}
+	if err := Bind(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	setSource("configCmdline", SourceCommandLine)
+
+	base := writeConfig(t, dir, "base.yaml", `
+configAddr: ":80"
+configTimeout: 10s
+configHosts: [a, b]
+configMongo:
+  router: base.json
+  limit: 10
+configCmdline: config
+`)
+	overlay := writeConfig(t, dir, "prod.json", `{"configAddr": ":443", "configMongo": {"limit": 20}}`)
+	extra := writeConfig(t, dir, "extra.toml", `
+configRatio = 0.5
+[configMongo]
+router = "prod.json"
+`)
+	if err := LoadConfig(base, overlay, extra); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Addr != ":443" || cfg.Timeout != time.Second*10 || cfg.Ratio != 0.5 ||
+		!reflect.DeepEqual(cfg.Hosts, []string{"a", "b"}) ||
+		cfg.Mongo.Router != "prod.json" || cfg.Mongo.Limit != 20 {
+		t.Errorf("Wrong config: %+v", cfg)
+	}
+	if cfg.Cmdline != "cmdline" {
+		t.Errorf("Command line flag is overridden by config: %s", cfg.Cmdline)
+	}
+	if GetSource("configAddr") != SourceConfig {
+		t.Errorf("Source of configAddr = %s", GetSource("configAddr"))
+	}
+
+	unknown := writeConfig(t, dir, "unknown.yml", "configAddr: x\nconfigMongo:\n  typo: 1\nnoSuchFlag: 1\n")
+	err = LoadConfig(unknown)
+	if err == nil || !strings.Contains(err.Error(), "configMongo.typo, noSuchFlag") {
+		t.Errorf("Unknown keys error = %v", err)
+	}
+	if cfg.Addr != ":443" {
+		t.Errorf("Config with unknown keys is partially applied: %s", cfg.Addr)
+	}
+
+	invalid := writeConfig(t, dir, "invalid.json", `{"configTimeout": "ten"}`)
+	if err := LoadConfig(invalid); err == nil {
+		t.Error("No error for invalid value")
+	}
+	// configAddr is sorted before configTimeout, and is restored.
+	partial := writeConfig(t, dir, "partial.json", `{"configAddr": ":8080", "configMongo": {"limit": 30}, "configTimeout": "ten"}`)
+	if err := LoadConfig(partial); err == nil {
+		t.Error("No error for invalid value")
+	}
+	if cfg.Addr != ":443" || cfg.Mongo.Limit != 20 || cfg.Timeout != time.Second*10 {
+		t.Errorf("Config with invalid value is partially applied: %+v", cfg)
+	}
+
+	// Large numbers are not formatted in exponent form.
+	large := writeConfig(t, dir, "large.json", `{"configMongo": {"limit": 1000000}, "configRatio": 2500000.5}`)
+	if err := LoadConfig(large); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Mongo.Limit != 1000000 || cfg.Ratio != 2500000.5 {
+		t.Errorf("Wrong large numbers from json: %+v", cfg)
+	}
+	large = writeConfig(t, dir, "large.yaml", "configMongo:\n  limit: 2e6\n")
+	if err := LoadConfig(large); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Mongo.Limit != 2000000 {
+		t.Errorf("Wrong large numbers from yaml: %+v", cfg)
+	}
+	if err := LoadConfig(filepath.Join(dir, "config.ini")); err == nil {
+		t.Error("No error for unknown format")
+	}
+	if flag.Lookup("configFiles") == nil {
+		t.Error("--configFiles is not registered")
+	}
+}
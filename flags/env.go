@@ -0,0 +1,193 @@
+package flags
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Source tells where the value of a flag comes from.
+type Source int
+
+// Sources in the ascending order of precedence.
+const (
+	SourceDefault Source = iota
+	SourceConfig
+	SourceEnv
+	SourceCommandLine
+)
+
+var (
+	envPrefix  string
+	sources    = map[string]Source{}
+	sourceLock sync.RWMutex
+)
+
+func (s Source) String() string {
+	switch s {
+	case SourceDefault:
+		return "default"
+	case SourceConfig:
+		return "config"
+	case SourceEnv:
+		return "env"
+	case SourceCommandLine:
+		return "cmdline"
+	}
+	return fmt.Sprintf("Source(%d)", int(s))
+}
+
+// SetEnvPrefix sets the prefix of environment variable names. It should be
+// called before parsing.
+// Example usage:
+//   flags.SetEnvPrefix("myapp")
+//   // $MYAPP_DB_ROUTER_JSON is now bound to --dbRouterJson
+func SetEnvPrefix(prefix string) {
+	envPrefix = prefix
+}
+
+// EnvName returns the environment variable bound to the flag, which is the
+// flag name converted from camelCase to UPPER_SNAKE, e.g. requestTimeout to
+// REQUEST_TIMEOUT, with the prefix if any.
+func EnvName(name string) string {
+	if envPrefix != "" {
+		name = envPrefix + "_" + name
+	}
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			b.WriteRune('_')
+			continue
+		}
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			// Split before a new word, e.g. dbRouter, or the end of an
+			// acronym, e.g. HTTPServer.
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) ||
+				(unicode.IsUpper(prev) && i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+				b.WriteRune('_')
+			}
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
+
+// GetSource returns where the current value of the flag comes from.
+func GetSource(name string) Source {
+	sourceLock.RLock()
+	defer sourceLock.RUnlock()
+	return sources[name]
+}
+
+func setSource(name string, source Source) {
+	sourceLock.Lock()
+	defer sourceLock.Unlock()
+	sources[name] = source
+}
+
+// Parse parses the flags from all the sources, in the precedence of
+// command line > environment variables > config file > default.
+// parseConfig should parse the command line and apply the config file to the
+// flags not given in command line, e.g. iniflags.Parse. The config files given
+// by --configFiles are applied after that, see LoadConfig().
+// Only the flags registered by this package are bound to environment variables.
+// The required fields bound by Bind() are validated at last. If any flags are
+// Reloadable(), the config files are watched for Reload() afterwards.
+// With --dumpConfig, the effective config is printed and the process exits.
+func Parse(parseConfig func()) error {
+	return ParseCommand(parseConfig, nil, nil)
+}
+
+// ParseCommand is the same as Parse(), except args are parsed by fs as well,
+// e.g. the arguments after a subcommand name. The global flags given in args
+// are regarded as command line flags, if they are added to fs by
+// AddGlobalFlags().
+func ParseCommand(parseConfig func(), fs *flag.FlagSet, args []string) error {
+	flag.Parse()
+	cmdline := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) {
+		cmdline[f.Name] = true
+	})
+
+	if parseConfig != nil {
+		parseConfig()
+	}
+	flag.Visit(func(f *flag.Flag) {
+		if cmdline[f.Name] {
+			setSource(f.Name, SourceCommandLine)
+		} else {
+			setSource(f.Name, SourceConfig)
+		}
+	})
+
+	if fs != nil {
+		// Parsed after the config, since the flags set by fs are unknown to
+		// parseConfig.
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		fs.Visit(func(f *flag.Flag) {
+			cmdline[f.Name] = true
+			setSource(f.Name, SourceCommandLine)
+		})
+	}
+
+	// The config files may be given by environment variable, e.g. for the
+	// overlay of deploying environment.
+	if !cmdline["configFiles"] {
+		if err := applyEnv("configFiles"); err != nil {
+			return err
+		}
+	}
+	if err := LoadConfig(*configFiles...); err != nil {
+		return err
+	}
+
+	for name := range ptrs {
+		if cmdline[name] {
+			continue
+		}
+		if err := applyEnv(name); err != nil {
+			return err
+		}
+	}
+	if *dumpConfig != "" {
+		if err := DumpConfig(os.Stdout, *dumpConfig); err != nil {
+			return err
+		}
+		os.Exit(0)
+	}
+	if err := validateRequired(); err != nil {
+		return err
+	}
+	watchOnce.Do(watchConfig)
+	return nil
+}
+
+// AddGlobalFlags adds all the global flags to fs, except those with the same
+// names defined in fs, so that they are accepted after a subcommand name.
+func AddGlobalFlags(fs *flag.FlagSet) {
+	flag.VisitAll(func(f *flag.Flag) {
+		if fs.Lookup(f.Name) == nil {
+			fs.Var(f.Value, f.Name, f.Usage)
+		}
+	})
+}
+
+func applyEnv(name string) error {
+	env := EnvName(name)
+	value, exists := os.LookupEnv(env)
+	if !exists {
+		return nil
+	}
+	if err := flag.Set(name, value); err != nil {
+		return fmt.Errorf("Parse $%s for --%s: %v", env, name, err)
+	}
+	setSource(name, SourceEnv)
+	return nil
+}
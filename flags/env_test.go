@@ -0,0 +1,53 @@
+package flags
+
+import (
+	"os"
+	"testing"
+)
+
+func TestEnvName(t *testing.T) {
+	cases := map[string]string{
+		"debug":           "DEBUG",
+		"dbRouterJson":    "DB_ROUTER_JSON",
+		"numDbConcurrent": "NUM_DB_CONCURRENT",
+		"HTTPServer":      "HTTP_SERVER",
+		"proxyURL":        "PROXY_URL",
+		"retry3Times":     "RETRY3_TIMES",
+		"log.level":       "LOG_LEVEL",
+	}
+	for name, want := range cases {
+		if got := EnvName(name); got != want {
+			t.Errorf("EnvName(%s) = %s, want %s", name, got, want)
+		}
+	}
+
+	SetEnvPrefix("myApp")
+	defer SetEnvPrefix("")
+	if got := EnvName("dbRouterJson"); got != "MY_APP_DB_ROUTER_JSON" {
+		t.Errorf("EnvName with prefix = %s", got)
+	}
+}
+
+func TestParseEnv(t *testing.T) {
+	fromEnv := String("envTestFromEnv", "default", "")
+	untouched := Int("envTestUntouched", 3, "")
+	invalid := Int("envTestInvalid", 3, "")
+
+	os.Setenv("ENV_TEST_FROM_ENV", "env")
+	defer os.Unsetenv("ENV_TEST_FROM_ENV")
+	if err := Parse(nil); err != nil {
+		t.Fatal(err)
+	}
+	if *fromEnv != "env" || GetSource("envTestFromEnv") != SourceEnv {
+		t.Errorf("envTestFromEnv = %s from %s", *fromEnv, GetSource("envTestFromEnv"))
+	}
+	if *untouched != 3 || GetSource("envTestUntouched") != SourceDefault {
+		t.Errorf("envTestUntouched = %d from %s", *untouched, GetSource("envTestUntouched"))
+	}
+
+	os.Setenv("ENV_TEST_INVALID", "three")
+	defer os.Unsetenv("ENV_TEST_INVALID")
+	if err := Parse(nil); err == nil {
+		t.Errorf("No error for invalid env, got %d", *invalid)
+	}
+}
@@ -4,6 +4,7 @@ package flags
 import (
 	"flag"
 	"fmt"
+	"net/url"
 	"os"
 	"strings"
 	"time"
@@ -22,22 +23,38 @@ func ValidateNonZero(names ...string) error {
 		}
 
 		check := false
-		switch ptr.(type) {
+		switch v := ptr.(type) {
 		case *bool:
 			// It's trivial to check a bool, since it makes the flag no sense(always true).
-			check = *ptr.(*bool)
+			check = *v
 		case *string:
-			check = *ptr.(*string) != ""
+			check = *v != ""
 		case *time.Duration:
-			check = *ptr.(*int64) > 0
+			check = *v != 0
 		case *float64:
-			check = *ptr.(*float64) != 0
+			check = *v != 0
 		case *int:
-			check = *ptr.(*int) != 0
+			check = *v != 0
+		case *int64:
+			check = *v != 0
+		case *uint:
+			check = *v != 0
+		case *uint64:
+			check = *v != 0
 		case *[]string:
-			check = len(*ptr.(*[]string)) > 0
-		case *os.File:
-			check = *ptr.(**os.File) != nil
+			check = len(*v) > 0
+		case *[]int:
+			check = len(*v) > 0
+		case *[]time.Duration:
+			check = len(*v) > 0
+		case *map[string]string:
+			check = len(*v) > 0
+		case *time.Time:
+			check = !v.IsZero()
+		case **url.URL:
+			check = *v != nil
+		case **os.File:
+			check = *v != nil
 		default:
 			// NOTE: Custom flags not supported.
 			check = true
@@ -0,0 +1,111 @@
+package flags
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	dumpConfig = Enum("dumpConfig", "", []string{"yaml", "json"}, "Print the effective config and exit. The output can be loaded by --configFiles, except secrets.")
+)
+
+// FlagInfo describes a flag registered by this package. Secrets are masked in
+// Default and Value.
+type FlagInfo struct {
+	Name    string `json:"name" yaml:"name"`
+	Type    string `json:"type" yaml:"type"`
+	Default string `json:"default" yaml:"default"`
+	Value   string `json:"value" yaml:"value"`
+	Source  Source `json:"source" yaml:"source"`
+	Usage   string `json:"usage" yaml:"usage"`
+	Secret  bool   `json:"secret,omitempty" yaml:"secret,omitempty"`
+}
+
+func (s Source) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// List returns all the flags registered by this package, sorted by name.
+// Example usage:
+//   for _, info := range flags.List() {
+//       goutils.LogInfo(info.Name, info.Value, info.Source)
+//   }
+func List() []*FlagInfo {
+	var ret []*FlagInfo
+	for _, name := range registeredNames() {
+		f := flag.Lookup(name)
+		_, secret := f.Value.(*secretValue)
+		ret = append(ret, &FlagInfo{
+			Name:    name,
+			Type:    flagType(f, ptrs[name]),
+			Default: f.DefValue,
+			Value:   f.Value.String(),
+			Source:  GetSource(name),
+			Usage:   f.Usage,
+			Secret:  secret,
+		})
+	}
+	return ret
+}
+
+func registeredNames() []string {
+	var names []string
+	for name := range ptrs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func flagType(f *flag.Flag, ptr interface{}) string {
+	switch f.Value.(type) {
+	case *enumValue:
+		return "enum"
+	case *byteSizeValue:
+		return "bytes"
+	case *secretValue:
+		return "secret"
+	case *muxValue:
+		return "custom"
+	}
+	return reflect.TypeOf(ptr).Elem().String()
+}
+
+// DumpConfig writes the current values of all the flags registered by this
+// package in yaml or json, which can be loaded by LoadConfig(). Secrets are
+// left out.
+func DumpConfig(w io.Writer, format string) error {
+	values := map[string]interface{}{}
+	for _, name := range registeredNames() {
+		f := flag.Lookup(name)
+		if _, secret := f.Value.(*secretValue); secret || name == "dumpConfig" {
+			continue
+		}
+		switch ptr := ptrs[name].(type) {
+		case *bool:
+			values[name] = *ptr
+		case *int:
+			values[name] = *ptr
+		case *float64:
+			values[name] = *ptr
+		default:
+			values[name] = f.Value.String()
+		}
+	}
+
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(values)
+	case "yaml":
+		return yaml.NewEncoder(w).Encode(values)
+	}
+	return fmt.Errorf("Unknown dump format %s", format)
+}
@@ -0,0 +1,116 @@
+package flags
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func findFlag(infos []*FlagInfo, name string) *FlagInfo {
+	for _, info := range infos {
+		if info.Name == name {
+			return info
+		}
+	}
+	return nil
+}
+
+func TestList(t *testing.T) {
+	Int("inspectInt", 3, "An int.")
+	Bytes("inspectSize", 1<<10, "")
+	Secret("inspectSecret", "")
+	flag.Set("inspectInt", "4")
+	setSource("inspectInt", SourceEnv)
+	flag.Set("inspectSecret", "p@ss")
+
+	infos := List()
+	for i := 1; i < len(infos); i++ {
+		if infos[i-1].Name > infos[i].Name {
+			t.Fatal("Flags are not sorted")
+		}
+	}
+	info := findFlag(infos, "inspectInt")
+	if info == nil || info.Type != "int" || info.Default != "3" || info.Value != "4" ||
+		info.Source != SourceEnv || info.Usage != "An int." {
+		t.Errorf("Wrong info: %+v", info)
+	}
+	if info := findFlag(infos, "inspectSize"); info == nil || info.Type != "bytes" || info.Value != "1KB" {
+		t.Errorf("Wrong info: %+v", info)
+	}
+	info = findFlag(infos, "inspectSecret")
+	if info == nil || !info.Secret || info.Value != secretMask {
+		t.Errorf("Wrong info: %+v", info)
+	}
+
+	data, err := json.Marshal(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "p@ss") || !strings.Contains(string(data), `"source":"default"`) {
+		t.Errorf("Json = %s", data)
+	}
+}
+
+func TestDumpConfig(t *testing.T) {
+	var cfg struct {
+		Timeout  time.Duration `flag:"dumpTimeout" default:"10s"`
+		Interval time.Duration `flag:"dumpInterval"`
+		MaxSize  int           `flag:"dumpMaxSize"`
+		Enabled  bool          `flag:"dumpEnabled"`
+		Hosts    []string      `flag:"dumpHosts"`
+		Password string        `flag:"dumpPassword" secret:"true"`
+		Mongo    struct {
+			Limit int
+		} `flag:"dumpMongo"`
+	}
+	if err := Bind(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	flag.Set("dumpInterval", "1h30m")
+	flag.Set("dumpMaxSize", "1000000")
+	flag.Set("dumpEnabled", "true")
+	flag.Set("dumpHosts", "a,b")
+	flag.Set("dumpPassword", "p@ss")
+	flag.Set("dumpMongo.limit", "5")
+
+	dir, err := ioutil.TempDir("", "dump")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, format := range []string{"yaml", "json"} {
+		buf := &bytes.Buffer{}
+		if err := DumpConfig(buf, format); err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(buf.String(), "p@ss") || strings.Contains(buf.String(), "dumpPassword") {
+			t.Errorf("Secret is dumped:\n%s", buf.String())
+		}
+
+		// Dumps are loaded back as config.
+		filename := filepath.Join(dir, "dump."+format)
+		ioutil.WriteFile(filename, buf.Bytes(), 0644)
+		flag.Set("dumpTimeout", "0s")
+		flag.Set("dumpInterval", "0s")
+		flag.Set("dumpMaxSize", "0")
+		flag.Set("dumpEnabled", "false")
+		flag.Set("dumpMongo.limit", "0")
+		if err := LoadConfig(filename); err != nil {
+			t.Fatal(err)
+		}
+		if cfg.Timeout != time.Second*10 || cfg.Interval != time.Minute*90 || cfg.MaxSize != 1000000 || !cfg.Enabled || len(cfg.Hosts) != 2 || cfg.Mongo.Limit != 5 {
+			t.Errorf("Wrong config loaded from %s dump: %+v", format, cfg)
+		}
+	}
+
+	if err := DumpConfig(ioutil.Discard, "xml"); err == nil {
+		t.Error("No error for unknown format")
+	}
+}
@@ -0,0 +1,217 @@
+package flags
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+var (
+	reloadable = map[string]bool{}
+	callbacks  = map[string][]func(){}
+	reloadLock sync.Mutex
+	// valueLock guards the values of flags against the readers of Get().
+	valueLock sync.RWMutex
+	watchOnce sync.Once
+
+	errLog = log.New(os.Stderr, "[ERROR]", log.LstdFlags)
+)
+
+// the delay to merge the burst of file events, e.g. by editors.
+const reloadDelay = time.Millisecond * 100
+
+// Reloadable marks the flags to be updated by Reload() at runtime. The code
+// reading reloadable flags should subscribe the changes with OnChange().
+func Reloadable(names ...string) {
+	reloadLock.Lock()
+	defer reloadLock.Unlock()
+	for _, name := range names {
+		reloadable[name] = true
+	}
+}
+
+// OnChange registers the callback once the flag is changed by Reload().
+// Callbacks are called in the same goroutine after all the changed flags are
+// set, so it's safe to read the flags within. Callbacks must not call
+// OnChange() or Reload(). Reloadable flags read by other goroutines should be
+// read by Get().
+// Example usage:
+//   timeout := flags.Int("timeout", 10, "Timeout in sec.")
+//   flags.Reloadable("timeout")
+//   flags.OnChange("timeout", func() {
+//       atomic.StoreInt64(&currentTimeout, int64(*timeout))
+//   })
+func OnChange(name string, fn func()) {
+	reloadLock.Lock()
+	defer reloadLock.Unlock()
+	callbacks[name] = append(callbacks[name], fn)
+}
+
+// Reload re-reads the config files given by --configFiles, and updates the
+// reloadable flags. Flags given by command line or environment variables, and
+// the keys removed from the config files keep the current values.
+// It's called on SIGHUP or changes of local config files after Parse(), if
+// there are any reloadable flags.
+func Reload() error {
+	reloadLock.Lock()
+	defer reloadLock.Unlock()
+
+	values, err := readConfig(*configFiles...)
+	if err != nil {
+		return err
+	}
+
+	var changed []string
+	valueLock.Lock()
+	for name, value := range values {
+		if !reloadable[name] || GetSource(name) > SourceConfig {
+			continue
+		}
+		f := flag.Lookup(name)
+		old, oldString := currentValue(f), rawValue(f)
+		if setErr := f.Value.Set(value); setErr != nil {
+			// Some values are reset even if failed, e.g. ints.
+			if f.Value.String() != oldString {
+				f.Value.Set(oldString)
+			}
+			// The other flags are still updated, since their callbacks are
+			// independent.
+			err = fmt.Errorf("Parse config %s: %v", name, setErr)
+			continue
+		}
+		setSource(name, SourceConfig)
+		if currentValue(f) != old {
+			changed = append(changed, name)
+		}
+	}
+	valueLock.Unlock()
+
+	sort.Strings(changed)
+	for _, name := range changed {
+		for _, fn := range callbacks[name] {
+			fn()
+		}
+	}
+	return err
+}
+
+// Get returns the value of the flag, which is safe to call while the flag is
+// being reloaded by Reload() in another goroutine.
+// Example usage:
+//   timeout := flags.Duration("timeout", time.Second*10, "Request timeout.")
+//   flags.Reloadable("timeout")
+//   ...
+//   client.Timeout = flags.Get(timeout)
+func Get[T any](ptr *T) T {
+	valueLock.RLock()
+	defer valueLock.RUnlock()
+	return *ptr
+}
+
+// currentValue returns the flag value in string for comparison. Getter is
+// preferred since String() may be masked, e.g. secrets.
+func currentValue(f *flag.Flag) string {
+	if getter, ok := f.Value.(flag.Getter); ok {
+		return fmt.Sprint(getter.Get())
+	}
+	return f.Value.String()
+}
+
+// watchConfig reloads the config files on SIGHUP or file changes, in the
+// background.
+func watchConfig() {
+	reloadLock.Lock()
+	enabled := len(reloadable) > 0 && len(*configFiles) > 0
+	reloadLock.Unlock()
+	if !enabled {
+		return
+	}
+
+	reload := make(chan struct{}, 1)
+	trigger := func() {
+		select {
+		case reload <- struct{}{}:
+		default:
+		}
+	}
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGHUP)
+	go func() {
+		for range sig {
+			trigger()
+		}
+	}()
+	watchConfigFiles(*configFiles, trigger)
+
+	go func() {
+		for range reload {
+			if err := Reload(); err != nil {
+				errLog.Println("Reload config:", err)
+			}
+		}
+	}()
+}
+
+func watchConfigFiles(uris []string, trigger func()) {
+	files := map[string]bool{}
+	for _, uri := range uris {
+		if u, err := url.Parse(uri); err == nil && u.Scheme != "" {
+			// Remote configs are only reloaded by SIGHUP.
+			continue
+		}
+		if abs, err := filepath.Abs(uri); err == nil {
+			files[abs] = true
+		}
+	}
+	if len(files) == 0 {
+		return
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		errLog.Println("Watch config:", err)
+		return
+	}
+	// Directories are watched instead of files, since most of the editors and
+	// deploying tools replace the file by renaming.
+	for file := range files {
+		if err := watcher.Add(filepath.Dir(file)); err != nil {
+			errLog.Println("Watch config:", err)
+		}
+	}
+
+	go func() {
+		var timer *time.Timer
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if !files[filepath.Clean(event.Name)] || event.Op == fsnotify.Chmod {
+					continue
+				}
+				if timer != nil {
+					timer.Stop()
+				}
+				timer = time.AfterFunc(reloadDelay, trigger)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				errLog.Println("Watch config:", err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,102 @@
+package flags
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "reload")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var cfg struct {
+		Timeout int    `flag:"reloadTimeout" reloadable:"true"`
+		Addr    string `flag:"reloadAddr"`
+		Env     string `flag:"reloadEnv" reloadable:"true"`
+	}
+	if err := Bind(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	setSource("reloadEnv", SourceEnv)
+
+	var changes []string
+	for _, name := range []string{"reloadTimeout", "reloadAddr", "reloadEnv"} {
+		name := name
+		OnChange(name, func() {
+			changes = append(changes, name)
+		})
+	}
+
+	filename := writeConfig(t, dir, "app.yaml", "reloadTimeout: 10\nreloadAddr: a\nreloadEnv: config\n")
+	*configFiles = []string{filename}
+	defer func() {
+		*configFiles = nil
+	}()
+	if err := LoadConfig(*configFiles...); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Timeout != 10 || cfg.Addr != "a" || cfg.Env != "" {
+		t.Fatalf("Wrong config: %+v", cfg)
+	}
+
+	writeConfig(t, dir, "app.yaml", "reloadTimeout: 20\nreloadAddr: b\nreloadEnv: config\n")
+	if err := Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Timeout != 20 || cfg.Addr != "a" || cfg.Env != "" {
+		t.Errorf("Wrong reloaded config: %+v", cfg)
+	}
+	if !reflect.DeepEqual(changes, []string{"reloadTimeout"}) {
+		t.Errorf("Changes = %v", changes)
+	}
+
+	// Reloading while reading by Get() in another goroutine, see -race.
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				Get(&cfg.Timeout)
+			}
+		}
+	}()
+	writeConfig(t, dir, "app.yaml", "reloadTimeout: 30\nreloadAddr: b\nreloadEnv: config\n")
+	if err := Reload(); err != nil {
+		t.Fatal(err)
+	}
+	close(stop)
+	<-done
+	if Get(&cfg.Timeout) != 30 {
+		t.Errorf("Get() = %d after reload", Get(&cfg.Timeout))
+	}
+
+	changes = nil
+	if err := Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("Callbacks called without change: %v", changes)
+	}
+
+	writeConfig(t, dir, "app.yaml", "reloadTimeout: twenty\n")
+	if err := Reload(); err == nil {
+		t.Error("No error for invalid value")
+	}
+	if cfg.Timeout != 30 {
+		t.Errorf("Timeout = %d after invalid reload", cfg.Timeout)
+	}
+	os.Remove(filepath.Join(dir, "app.yaml"))
+	if err := Reload(); err == nil {
+		t.Error("No error for missing config")
+	}
+}
@@ -0,0 +1,387 @@
+package flags
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type enumValue struct {
+	s       *string
+	allowed []string
+}
+
+func (e *enumValue) String() string {
+	if e == nil || e.s == nil {
+		return ""
+	}
+	return *e.s
+}
+
+func (e *enumValue) Set(value string) error {
+	for _, allowed := range e.allowed {
+		if value == allowed {
+			*e.s = value
+			return nil
+		}
+	}
+	return fmt.Errorf("%q is not one of %s", value, strings.Join(e.allowed, "|"))
+}
+
+type mapValue struct {
+	m *map[string]string
+}
+
+func (mv *mapValue) String() string {
+	if mv == nil || mv.m == nil {
+		return ""
+	}
+	var keys []string
+	for k := range *mv.m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = k + "=" + (*mv.m)[k]
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (mv *mapValue) Set(value string) error {
+	m := map[string]string{}
+	if value != "" {
+		for _, pair := range strings.Split(value, ",") {
+			kv := strings.SplitN(pair, "=", 2)
+			if len(kv) != 2 {
+				return fmt.Errorf("%q is not in the form of key=value", pair)
+			}
+			m[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+		}
+	}
+	*mv.m = m
+	return nil
+}
+
+var (
+	byteSizePattern = regexp.MustCompile(`^(?i)([0-9]*\.?[0-9]+)\s*([KMGTP]?)(I?B?)$`)
+	byteUnits       = []string{"", "K", "M", "G", "T", "P"}
+)
+
+type byteSizeValue struct {
+	n *int64
+}
+
+func (b *byteSizeValue) String() string {
+	if b == nil || b.n == nil {
+		return ""
+	}
+	n := *b.n
+	unit := 0
+	for n != 0 && n%1024 == 0 && unit < len(byteUnits)-1 {
+		n /= 1024
+		unit++
+	}
+	return strconv.FormatInt(n, 10) + byteUnits[unit] + "B"
+}
+
+func (b *byteSizeValue) Set(value string) error {
+	n, err := ParseByteSize(value)
+	if err != nil {
+		return err
+	}
+	*b.n = n
+	return nil
+}
+
+// ParseByteSize parses the size in bytes, e.g. 512MB, 1.5G or 100. Units are
+// in power of 1024, i.e. KB and KiB are the same.
+func ParseByteSize(s string) (int64, error) {
+	m := byteSizePattern.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil || (m[2] == "" && strings.HasPrefix(strings.ToLower(m[3]), "i")) {
+		return 0, fmt.Errorf("Invalid byte size %q", s)
+	}
+	f, err := strconv.ParseFloat(m[1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid byte size %q", s)
+	}
+	if m[2] == "" && f != math.Trunc(f) {
+		return 0, fmt.Errorf("Fractional byte size %q", s)
+	}
+	for i, unit := range byteUnits {
+		if strings.EqualFold(m[2], unit) {
+			f *= float64(int64(1) << (10 * uint(i)))
+			break
+		}
+	}
+	// float64(math.MaxInt64) is rounded up to 1<<63, which overflows int64.
+	if f >= math.MaxInt64 {
+		return 0, fmt.Errorf("Byte size %q overflows int64", s)
+	}
+	return int64(f), nil
+}
+
+type urlValue struct {
+	u **url.URL
+}
+
+func (uv *urlValue) String() string {
+	if uv == nil || uv.u == nil || *uv.u == nil {
+		return ""
+	}
+	return (*uv.u).String()
+}
+
+func (uv *urlValue) Set(value string) error {
+	if value == "" {
+		*uv.u = nil
+		return nil
+	}
+	u, err := url.Parse(value)
+	if err != nil {
+		return err
+	}
+	if u.Scheme == "" {
+		return fmt.Errorf("Missing scheme in url %q", value)
+	}
+	*uv.u = u
+	return nil
+}
+
+type intSliceValue struct {
+	s *[]int
+}
+
+func (is *intSliceValue) String() string {
+	if is == nil || is.s == nil {
+		return ""
+	}
+	items := make([]string, len(*is.s))
+	for i, n := range *is.s {
+		items[i] = strconv.Itoa(n)
+	}
+	return strings.Join(items, ",")
+}
+
+func (is *intSliceValue) Set(value string) error {
+	var s []int
+	if value != "" {
+		for _, item := range strings.Split(value, ",") {
+			n, err := strconv.Atoi(strings.TrimSpace(item))
+			if err != nil {
+				return err
+			}
+			s = append(s, n)
+		}
+	}
+	*is.s = s
+	return nil
+}
+
+type durationSliceValue struct {
+	s *[]time.Duration
+}
+
+func (ds *durationSliceValue) String() string {
+	if ds == nil || ds.s == nil {
+		return ""
+	}
+	items := make([]string, len(*ds.s))
+	for i, d := range *ds.s {
+		items[i] = d.String()
+	}
+	return strings.Join(items, ",")
+}
+
+func (ds *durationSliceValue) Set(value string) error {
+	var s []time.Duration
+	if value != "" {
+		for _, item := range strings.Split(value, ",") {
+			d, err := time.ParseDuration(strings.TrimSpace(item))
+			if err != nil {
+				return err
+			}
+			s = append(s, d)
+		}
+	}
+	*ds.s = s
+	return nil
+}
+
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+type timeValue struct {
+	t *time.Time
+}
+
+func (tv *timeValue) String() string {
+	if tv == nil || tv.t == nil || tv.t.IsZero() {
+		return ""
+	}
+	return tv.t.Format(time.RFC3339Nano)
+}
+
+func (tv *timeValue) Set(value string) error {
+	if value == "" {
+		*tv.t = time.Time{}
+		return nil
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			*tv.t = t
+			return nil
+		}
+	}
+	return fmt.Errorf("Invalid time %q, expect RFC3339 or 2006-01-02", value)
+}
+
+const (
+	secretMask       = "******"
+	secretFilePrefix = "file://"
+)
+
+type secretValue struct {
+	s *string
+}
+
+// String masks the secret, so that it's never shown in usage or dumps.
+func (sv *secretValue) String() string {
+	if sv == nil || sv.s == nil || *sv.s == "" {
+		return ""
+	}
+	return secretMask
+}
+
+func (sv *secretValue) Set(value string) error {
+	if strings.HasPrefix(value, secretFilePrefix) {
+		data, err := ioutil.ReadFile(strings.TrimPrefix(value, secretFilePrefix))
+		if err != nil {
+			return err
+		}
+		value = strings.TrimRight(string(data), "\r\n")
+	}
+	*sv.s = value
+	return nil
+}
+
+// Get returns the secret in plain text, e.g. to detect the changes in Reload().
+func (sv *secretValue) Get() interface{} {
+	return *sv.s
+}
+
+// Enum binds flag with string type, whose value must be one of allowed. The
+// allowed values are appended to the usage. The default value may be empty for
+// not set, otherwise it panics if it's not allowed.
+func Enum(name string, defaultValue string, allowed []string, usage string) *string {
+	if ptr, exists := ptrs[name]; exists {
+		return ptr.(*string)
+	}
+	ptr := new(string)
+	value := &enumValue{ptr, allowed}
+	if defaultValue != "" {
+		if err := value.Set(defaultValue); err != nil {
+			panic(fmt.Sprintf("Invalid default of --%s: %v", name, err))
+		}
+	}
+	flag.Var(value, name, fmt.Sprintf("%s One of %s.", usage, strings.Join(allowed, "|")))
+	ptrs[name] = ptr
+	return ptr
+}
+
+// Map binds flag with map type, in the form of k1=v1,k2=v2.
+func Map(name string, defaultValue map[string]string, usage string) *map[string]string {
+	if ptr, exists := ptrs[name]; exists {
+		return ptr.(*map[string]string)
+	}
+	ptr := &defaultValue
+	flag.Var(&mapValue{ptr}, name, usage)
+	ptrs[name] = ptr
+	return ptr
+}
+
+// Bytes binds flag with byte size, e.g. 512MB. See ParseByteSize() for the
+// format.
+func Bytes(name string, defaultValue int64, usage string) *int64 {
+	if ptr, exists := ptrs[name]; exists {
+		return ptr.(*int64)
+	}
+	ptr := &defaultValue
+	flag.Var(&byteSizeValue{ptr}, name, usage)
+	ptrs[name] = ptr
+	return ptr
+}
+
+// URL binds flag with *url.URL type. An empty value is parsed to nil, and it
+// panics if the default value is invalid.
+func URL(name string, defaultValue string, usage string) **url.URL {
+	if ptr, exists := ptrs[name]; exists {
+		return ptr.(**url.URL)
+	}
+	ptr := new(*url.URL)
+	value := &urlValue{ptr}
+	if err := value.Set(defaultValue); err != nil {
+		panic(fmt.Sprintf("Invalid default of --%s: %v", name, err))
+	}
+	flag.Var(value, name, usage)
+	ptrs[name] = ptr
+	return ptr
+}
+
+// IntSlice binds flag with []int type, in the form of 1,2,3.
+func IntSlice(name string, defaultValue []int, usage string) *[]int {
+	if ptr, exists := ptrs[name]; exists {
+		return ptr.(*[]int)
+	}
+	ptr := &defaultValue
+	flag.Var(&intSliceValue{ptr}, name, usage)
+	ptrs[name] = ptr
+	return ptr
+}
+
+// DurationSlice binds flag with []time.Duration type, in the form of 1s,1m.
+func DurationSlice(name string, defaultValue []time.Duration, usage string) *[]time.Duration {
+	if ptr, exists := ptrs[name]; exists {
+		return ptr.(*[]time.Duration)
+	}
+	ptr := &defaultValue
+	flag.Var(&durationSliceValue{ptr}, name, usage)
+	ptrs[name] = ptr
+	return ptr
+}
+
+// Time binds flag with time.Time type, in RFC3339 or the date form like
+// 2006-01-02. Time without zone is parsed in the local timezone.
+func Time(name string, defaultValue time.Time, usage string) *time.Time {
+	if ptr, exists := ptrs[name]; exists {
+		return ptr.(*time.Time)
+	}
+	ptr := &defaultValue
+	flag.Var(&timeValue{ptr}, name, usage)
+	ptrs[name] = ptr
+	return ptr
+}
+
+// Secret binds flag with string type, whose value is masked in usage and
+// dumps. The value in the form of file:///path/to/secret is read from the file,
+// with the trailing newline trimmed.
+func Secret(name string, usage string) *string {
+	if ptr, exists := ptrs[name]; exists {
+		return ptr.(*string)
+	}
+	ptr := new(string)
+	flag.Var(&secretValue{ptr}, name, usage)
+	ptrs[name] = ptr
+	return ptr
+}
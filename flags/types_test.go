@@ -0,0 +1,197 @@
+package flags
+
+import (
+	"flag"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEnum(t *testing.T) {
+	level := Enum("typesLevel", "info", []string{"debug", "info", "error"}, "Log level.")
+	if !strings.Contains(flag.Lookup("typesLevel").Usage, "One of debug|info|error.") {
+		t.Errorf("Usage = %s", flag.Lookup("typesLevel").Usage)
+	}
+	if err := flag.Set("typesLevel", "warn"); err == nil || *level != "info" {
+		t.Errorf("Invalid enum: %v, %s", err, *level)
+	}
+	if err := flag.Set("typesLevel", "debug"); err != nil || *level != "debug" {
+		t.Errorf("Valid enum: %v, %s", err, *level)
+	}
+	if Enum("typesLevel", "", nil, "") != level {
+		t.Error("Duplicated registration returns another ptr")
+	}
+
+	if *Enum("typesFormat", "", []string{"yaml", "json"}, "") != "" {
+		t.Error("Empty default is not kept")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("No panic for invalid default")
+		}
+		if flag.Lookup("typesMode") != nil {
+			t.Error("Flag with invalid default is registered")
+		}
+	}()
+	Enum("typesMode", "fast", []string{"debug", "release"}, "")
+}
+
+func TestMap(t *testing.T) {
+	m := Map("typesMap", map[string]string{"a": "1"}, "")
+	if err := flag.Set("typesMap", "b=2, c = x=y"); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*m, map[string]string{"b": "2", "c": "x=y"}) {
+		t.Errorf("Map = %v", *m)
+	}
+	if got := flag.Lookup("typesMap").Value.String(); got != "b=2,c=x=y" {
+		t.Errorf("String() = %s", got)
+	}
+	if err := flag.Set("typesMap", "b"); err == nil {
+		t.Error("No error for missing value")
+	}
+}
+
+func TestBytes(t *testing.T) {
+	cases := map[string]int64{
+		"100":    100,
+		"512MB":  512 << 20,
+		"1.5g":   3 << 29,
+		"2KiB":   2048,
+		"1 TB":   1 << 40,
+		"16b":    16,
+		"0.5kb":  512,
+		"10M":    10 << 20,
+		"0":      0,
+		"3PB":    3 << 50,
+		"  7K  ": 7 << 10,
+	}
+	for s, want := range cases {
+		if got, err := ParseByteSize(s); err != nil || got != want {
+			t.Errorf("ParseByteSize(%s) = %d, %v, want %d", s, got, err, want)
+		}
+	}
+	for _, s := range []string{"", "MB", "1XB", "-1KB", "5iB", "1.5B", "100.5", "8192PB", "100000000000000000000"} {
+		if _, err := ParseByteSize(s); err == nil {
+			t.Errorf("No error for %q", s)
+		}
+	}
+
+	size := Bytes("typesSize", 1<<20, "")
+	if got := flag.Lookup("typesSize").Value.String(); got != "1MB" {
+		t.Errorf("String() = %s", got)
+	}
+	flag.Set("typesSize", "1500")
+	if *size != 1500 || flag.Lookup("typesSize").Value.String() != "1500B" {
+		t.Errorf("Size = %d", *size)
+	}
+}
+
+func TestURL(t *testing.T) {
+	u := URL("typesURL", "http://localhost:8080/api", "")
+	if (*u).Host != "localhost:8080" {
+		t.Errorf("Host = %s", (*u).Host)
+	}
+	if err := flag.Set("typesURL", "localhost"); err == nil {
+		t.Error("No error for missing scheme")
+	}
+	flag.Set("typesURL", "")
+	if *u != nil {
+		t.Errorf("URL = %v, want nil", *u)
+	}
+	if err := ValidateNonZero("typesURL"); err == nil {
+		t.Error("No error for nil url")
+	}
+}
+
+func TestSlices(t *testing.T) {
+	ints := IntSlice("typesInts", []int{1}, "")
+	if err := flag.Set("typesInts", "1, 2,3"); err != nil || !reflect.DeepEqual(*ints, []int{1, 2, 3}) {
+		t.Errorf("Ints = %v, %v", *ints, err)
+	}
+	if err := flag.Set("typesInts", "1,a"); err == nil {
+		t.Error("No error for invalid int")
+	}
+
+	durations := DurationSlice("typesDurations", nil, "")
+	if err := ValidateNonZero("typesDurations"); err == nil {
+		t.Error("No error for empty slice")
+	}
+	flag.Set("typesDurations", "1s,1m")
+	if !reflect.DeepEqual(*durations, []time.Duration{time.Second, time.Minute}) {
+		t.Errorf("Durations = %v", *durations)
+	}
+	if got := flag.Lookup("typesDurations").Value.String(); got != "1s,1m0s" {
+		t.Errorf("String() = %s", got)
+	}
+}
+
+func TestTime(t *testing.T) {
+	tm := Time("typesTime", time.Time{}, "")
+	flag.Set("typesTime", "2018-01-02T03:04:05+08:00")
+	if want := time.Date(2018, 1, 1, 19, 4, 5, 0, time.UTC); !tm.Equal(want) {
+		t.Errorf("Time = %v, want %v", *tm, want)
+	}
+	flag.Set("typesTime", "2018-01-02")
+	if want := time.Date(2018, 1, 2, 0, 0, 0, 0, time.Local); !tm.Equal(want) {
+		t.Errorf("Time = %v, want %v", *tm, want)
+	}
+	if err := flag.Set("typesTime", "yesterday"); err == nil {
+		t.Error("No error for invalid time")
+	}
+}
+
+func TestSecret(t *testing.T) {
+	secret := Secret("typesSecret", "Password of db.")
+	flag.Set("typesSecret", "p@ss")
+	if *secret != "p@ss" {
+		t.Errorf("Secret = %s", *secret)
+	}
+	if got := flag.Lookup("typesSecret").Value.String(); got != secretMask {
+		t.Errorf("String() = %s", got)
+	}
+
+	file, err := ioutil.TempFile("", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	file.WriteString("from-file\n")
+	file.Close()
+	flag.Set("typesSecret", "file://"+file.Name())
+	if *secret != "from-file" {
+		t.Errorf("Secret = %s", *secret)
+	}
+	if err := flag.Set("typesSecret", "file:///no/such/file"); err == nil || *secret != "from-file" {
+		t.Errorf("Missing file: %v, %s", err, *secret)
+	}
+}
+
+func TestBindTypes(t *testing.T) {
+	var cfg struct {
+		Level    string            `flag:"bindTypesLevel" enum:"debug|info" default:"info"`
+		Password string            `flag:"bindTypesPassword" secret:"true"`
+		Labels   map[string]string `flag:"bindTypesLabels" default:"a=1"`
+		Ports    []int             `flag:"bindTypesPorts" default:"80,443"`
+		Since    time.Time         `flag:"bindTypesSince" default:"2018-01-01"`
+		Endpoint *url.URL          `flag:"bindTypesEndpoint" default:"http://localhost"`
+	}
+	if err := Bind(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Level != "info" || cfg.Labels["a"] != "1" || !reflect.DeepEqual(cfg.Ports, []int{80, 443}) ||
+		cfg.Since.Year() != 2018 || cfg.Endpoint.Host != "localhost" {
+		t.Errorf("Wrong defaults: %+v", cfg)
+	}
+	flag.Set("bindTypesPassword", "secret")
+	if cfg.Password != "secret" || flag.Lookup("bindTypesPassword").Value.String() != secretMask {
+		t.Errorf("Password = %s", cfg.Password)
+	}
+	if err := flag.Set("bindTypesLevel", "warn"); err == nil {
+		t.Error("No error for invalid enum")
+	}
+}
@@ -0,0 +1,280 @@
+package gomap
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// BiMap is a thread-safe bijective map, where each value maps back to exactly
+// one key. It's useful to translate between two kinds of ids.
+// Example usage:
+//   ids := gomap.NewBiMap[string, int64]()
+//   ids.Set("user-a", 1001)
+//   name, ok := ids.LoadKey(1001)
+type BiMap[K comparable, V comparable] struct {
+	sync.RWMutex
+	forward map[K]V
+	inverse map[V]K
+}
+
+// NewBiMap creates a new map structure.
+func NewBiMap[K comparable, V comparable]() *BiMap[K, V] {
+	return &BiMap[K, V]{
+		forward: map[K]V{},
+		inverse: map[V]K{},
+	}
+}
+
+// WrapBiMap takes the ownership of a built-in map, and return a new map
+// structure. It fails if any value is shared by multiple keys.
+func WrapBiMap[K comparable, V comparable](m map[K]V) (*BiMap[K, V], error) {
+	if m == nil {
+		return NewBiMap[K, V](), nil
+	}
+	inverse, err := invert(m)
+	if err != nil {
+		return nil, err
+	}
+	return &BiMap[K, V]{
+		forward: m,
+		inverse: inverse,
+	}, nil
+}
+
+func invert[K comparable, V comparable](m map[K]V) (map[V]K, error) {
+	inverse := make(map[V]K, len(m))
+	for k, v := range m {
+		if other, ok := inverse[v]; ok {
+			return nil, fmt.Errorf("gomap: value %v is shared by keys %v and %v", v, other, k)
+		}
+		inverse[v] = k
+	}
+	return inverse, nil
+}
+
+// Unwrap releases the ownership of the inner built-in map from keys to values,
+// and return it. The same as Map, the map structure won't function any more.
+func (m *BiMap[K, V]) Unwrap() map[K]V {
+	m.Lock()
+	defer m.Unlock()
+
+	d := m.forward
+	m.forward, m.inverse = nil, nil
+	return d
+}
+
+// Clone copies the keys and values to a new map structure.
+func (m *BiMap[K, V]) Clone() *BiMap[K, V] {
+	m.RLock()
+	defer m.RUnlock()
+
+	ret := &BiMap[K, V]{
+		forward: make(map[K]V, len(m.forward)),
+		inverse: make(map[V]K, len(m.inverse)),
+	}
+	for k, v := range m.forward {
+		ret.forward[k] = v
+		ret.inverse[v] = k
+	}
+	return ret
+}
+
+// Inverse returns a *copy* of the map from values to keys.
+func (m *BiMap[K, V]) Inverse() *BiMap[V, K] {
+	m.RLock()
+	defer m.RUnlock()
+
+	ret := &BiMap[V, K]{
+		forward: make(map[V]K, len(m.inverse)),
+		inverse: make(map[K]V, len(m.forward)),
+	}
+	for k, v := range m.forward {
+		ret.forward[v] = k
+		ret.inverse[k] = v
+	}
+	return ret
+}
+
+// MarshalJSON implements the json.Marshaller interface, in the form of the map
+// from keys to values.
+func (m *BiMap[K, V]) MarshalJSON() ([]byte, error) {
+	m.RLock()
+	defer m.RUnlock()
+	return json.Marshal(m.forward)
+}
+
+// UnmarshalJSON implements the json.Unmarshaller interface. It replaces the
+// content, and fails if any value is shared by multiple keys.
+func (m *BiMap[K, V]) UnmarshalJSON(d []byte) error {
+	var forward map[K]V
+	if err := json.Unmarshal(d, &forward); err != nil {
+		return err
+	}
+	if forward == nil {
+		forward = map[K]V{}
+	}
+	inverse, err := invert(forward)
+	if err != nil {
+		return err
+	}
+	m.Lock()
+	m.forward, m.inverse = forward, inverse
+	m.Unlock()
+	return nil
+}
+
+// GobEncode implements the gob.GobEncoder interface, see Save().
+func (m *BiMap[K, V]) GobEncode() ([]byte, error) {
+	m.RLock()
+	defer m.RUnlock()
+	return gobEncode(m.forward)
+}
+
+// GobDecode implements the gob.GobDecoder interface, see Load(). It replaces
+// the content of the map.
+func (m *BiMap[K, V]) GobDecode(d []byte) error {
+	var forward map[K]V
+	if err := gobDecode(d, &forward); err != nil {
+		return err
+	}
+	if forward == nil {
+		forward = map[K]V{}
+	}
+	inverse, err := invert(forward)
+	if err != nil {
+		return err
+	}
+	m.Lock()
+	m.forward, m.inverse = forward, inverse
+	m.Unlock()
+	return nil
+}
+
+// Set maps the key and value to each other. The previous mappings of both the
+// key and the value are removed to keep the map bijective.
+func (m *BiMap[K, V]) Set(key K, value V) {
+	m.Lock()
+	defer m.Unlock()
+	if old, ok := m.forward[key]; ok {
+		delete(m.inverse, old)
+	}
+	if old, ok := m.inverse[value]; ok {
+		delete(m.forward, old)
+	}
+	m.forward[key] = value
+	m.inverse[value] = key
+}
+
+// TrySet is the same as Set(), except it does nothing and returns false if
+// either the key or the value exists.
+func (m *BiMap[K, V]) TrySet(key K, value V) bool {
+	m.Lock()
+	defer m.Unlock()
+	_, keyExists := m.forward[key]
+	_, valueExists := m.inverse[value]
+	if keyExists || valueExists {
+		return false
+	}
+	m.forward[key] = value
+	m.inverse[value] = key
+	return true
+}
+
+// Get returns the value by key, or the zero value if not exists.
+func (m *BiMap[K, V]) Get(key K) V {
+	m.RLock()
+	defer m.RUnlock()
+	return m.forward[key]
+}
+
+// Load is the same as Get(), except it reports whether the key exists.
+func (m *BiMap[K, V]) Load(key K) (V, bool) {
+	m.RLock()
+	defer m.RUnlock()
+	v, ok := m.forward[key]
+	return v, ok
+}
+
+// GetKey returns the key by value, or the zero value if not exists.
+func (m *BiMap[K, V]) GetKey(value V) K {
+	m.RLock()
+	defer m.RUnlock()
+	return m.inverse[value]
+}
+
+// LoadKey is the same as GetKey(), except it reports whether the value exists.
+func (m *BiMap[K, V]) LoadKey(value V) (K, bool) {
+	m.RLock()
+	defer m.RUnlock()
+	k, ok := m.inverse[value]
+	return k, ok
+}
+
+// Exists reports whether the key exists.
+func (m *BiMap[K, V]) Exists(key K) bool {
+	m.RLock()
+	defer m.RUnlock()
+	_, ok := m.forward[key]
+	return ok
+}
+
+// ExistsValue reports whether the value exists.
+func (m *BiMap[K, V]) ExistsValue(value V) bool {
+	m.RLock()
+	defer m.RUnlock()
+	_, ok := m.inverse[value]
+	return ok
+}
+
+// Delete removes the key and its value.
+func (m *BiMap[K, V]) Delete(key K) {
+	m.Lock()
+	defer m.Unlock()
+	if v, ok := m.forward[key]; ok {
+		delete(m.forward, key)
+		delete(m.inverse, v)
+	}
+}
+
+// DeleteValue removes the value and its key.
+func (m *BiMap[K, V]) DeleteValue(value V) {
+	m.Lock()
+	defer m.Unlock()
+	if k, ok := m.inverse[value]; ok {
+		delete(m.inverse, value)
+		delete(m.forward, k)
+	}
+}
+
+// Len returns the size of the map.
+func (m *BiMap[K, V]) Len() int {
+	m.RLock()
+	defer m.RUnlock()
+	return len(m.forward)
+}
+
+// GetKeys returns all the *copy* of keys in the same order of Map.GetKeys().
+func (m *BiMap[K, V]) GetKeys() []K {
+	m.RLock()
+	ret := make([]K, 0, len(m.forward))
+	for k := range m.forward {
+		ret = append(ret, k)
+	}
+	m.RUnlock()
+	sortKeys(ret)
+	return ret
+}
+
+// GetItems returns all the *copy* of key/value pairs, and sort them by keys in
+// the same order of GetKeys().
+func (m *BiMap[K, V]) GetItems() []Entry[K, V] {
+	m.RLock()
+	ret := make([]Entry[K, V], 0, len(m.forward))
+	for k, v := range m.forward {
+		ret = append(ret, Entry[K, V]{k, v})
+	}
+	m.RUnlock()
+	sortEntries(ret)
+	return ret
+}
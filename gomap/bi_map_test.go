@@ -0,0 +1,57 @@
+package gomap
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestBiMap(t *testing.T) {
+	m := NewBiMap[string, int]()
+	m.Set("a", 1)
+	m.Set("b", 2)
+	if m.GetKey(2) != "b" || m.Get("a") != 1 {
+		t.Error("Lookup not correct")
+	}
+	// Both the old mappings of "a" and 2 are removed.
+	m.Set("a", 2)
+	if m.Len() != 1 || m.Exists("b") || m.ExistsValue(1) || m.GetKey(2) != "a" {
+		t.Errorf("Set breaks the bijection, %v", m.GetItems())
+	}
+	if m.TrySet("c", 2) || m.TrySet("a", 3) || !m.TrySet("c", 3) {
+		t.Error("TrySet not correct")
+	}
+	inverse := m.Inverse()
+	if inverse.Get(3) != "c" || inverse.GetKey("a") != 2 {
+		t.Error("Inverse not correct")
+	}
+	m.DeleteValue(3)
+	if m.Exists("c") || !inverse.Exists(3) {
+		t.Error("DeleteValue not correct, or Inverse refers to the old memory")
+	}
+}
+
+func TestBiMapWrap(t *testing.T) {
+	if _, err := WrapBiMap(map[string]int{"a": 1, "b": 1}); err == nil {
+		t.Error("Shared values are accepted")
+	}
+	m, err := WrapBiMap(map[string]int{"b": 2, "a": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := m.GetItems()
+	if len(items) != 2 || items[0].Key != "a" || items[1].Value != 2 {
+		t.Errorf("GetItems returns %v", items)
+	}
+
+	d, err := json.Marshal(m.Clone())
+	if err != nil || string(d) != `{"a":1,"b":2}` {
+		t.Errorf("Marshal returns %s, %v", d, err)
+	}
+	decoded := NewBiMap[string, int]()
+	if err := json.Unmarshal(d, decoded); err != nil || decoded.GetKey(2) != "b" {
+		t.Errorf("Unmarshal not correct, %v", err)
+	}
+	if err := json.Unmarshal([]byte(`{"a":1,"b":1}`), decoded); err == nil || decoded.Len() != 2 {
+		t.Error("Unmarshal accepts shared values, or modifies the map on failure")
+	}
+}
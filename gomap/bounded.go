@@ -0,0 +1,293 @@
+package gomap
+
+import (
+	"sync"
+)
+
+// EvictionPolicy decides which entry to evict once a Bounded map is full.
+type EvictionPolicy int
+
+const (
+	// EvictLRU evicts the least recently used entry.
+	EvictLRU EvictionPolicy = iota
+	// EvictLFU evicts the least frequently used entry, and the least recently
+	// used one among the same frequency.
+	EvictLFU
+	// EvictARC is the adaptive replacement cache, which balances between
+	// recency and frequency by tracking the recently evicted keys. It resists
+	// the one-time scans better than LRU.
+	EvictARC
+)
+
+// CacheStats is the statistics of a bounded cache.
+type CacheStats struct {
+	Hits      int64
+	Misses    int64
+	Evictions int64
+}
+
+// Bounded is a thread-safe key-value structure bounded by the capacity. Once
+// the capacity is exceeded, entries are evicted by the policy. The size is the
+// number of entries by default, or the sum of the costs given by WithCost().
+// It's useful to cache objects built from unbounded inputs, where a plain Map
+// grows forever.
+// Example usage:
+//   cache := gomap.NewBounded[string, []byte](gomap.EvictLFU, 64<<20,
+//       gomap.WithCost(func(key string, value []byte) int {
+//           return len(value)
+//       }),
+//       gomap.WithOnEvict(func(key string, value []byte) {
+//           goutils.LogDebug("Evicted", key)
+//       }))
+type Bounded[K comparable, V any] struct {
+	sync.Mutex
+	capacity int
+	size     int
+	data     map[K]*boundedEntry[V]
+	policy   evictor[K]
+	costFn   func(K, V) int
+	onEvict  func(K, V)
+	stats    CacheStats
+	calls    map[K]*call[V]
+}
+
+type boundedEntry[V any] struct {
+	value V
+	cost  int
+}
+
+// BoundedOption customizes the Bounded map.
+type BoundedOption[K comparable, V any] func(*Bounded[K, V])
+
+// WithCost measures the size by the sum of fn for each entry, instead of the
+// number of entries. The cost of an entry is measured once it's set.
+func WithCost[K comparable, V any](fn func(key K, value V) int) BoundedOption[K, V] {
+	return func(m *Bounded[K, V]) {
+		m.costFn = fn
+	}
+}
+
+// WithOnEvict registers the callback of the entries evicted for the capacity,
+// but not those removed by Delete(). It's called without holding the lock, so
+// it may access the map.
+func WithOnEvict[K comparable, V any](fn func(key K, value V)) BoundedOption[K, V] {
+	return func(m *Bounded[K, V]) {
+		m.onEvict = fn
+	}
+}
+
+// NewBounded creates a new bounded map holding at most the capacity of
+// entries, or the costs if WithCost() is given. A non-positive capacity means
+// no bound.
+func NewBounded[K comparable, V any](policy EvictionPolicy, capacity int, opts ...BoundedOption[K, V]) *Bounded[K, V] {
+	m := &Bounded[K, V]{
+		capacity: capacity,
+		data:     map[K]*boundedEntry[V]{},
+	}
+	switch policy {
+	case EvictLFU:
+		m.policy = newLFUEvictor[K]()
+	case EvictARC:
+		m.policy = newARCEvictor[K]()
+	default:
+		m.policy = newLRUEvictor[K]()
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Get returns the value by key, and marks it as used.
+func (m *Bounded[K, V]) Get(key K) V {
+	v, _ := m.Load(key)
+	return v
+}
+
+// Load is the same as Get(), except it reports whether the key exists.
+func (m *Bounded[K, V]) Load(key K) (V, bool) {
+	m.Lock()
+	defer m.Unlock()
+	return m.load(key)
+}
+
+func (m *Bounded[K, V]) load(key K) (V, bool) {
+	if e, ok := m.data[key]; ok {
+		m.stats.Hits++
+		m.policy.access(key)
+		return e.value, true
+	}
+	m.stats.Misses++
+	var zero V
+	return zero, false
+}
+
+// Peek returns the value by key, without marking it as used or counting in the
+// statistics.
+func (m *Bounded[K, V]) Peek(key K) (V, bool) {
+	m.Lock()
+	defer m.Unlock()
+	if e, ok := m.data[key]; ok {
+		return e.value, true
+	}
+	var zero V
+	return zero, false
+}
+
+func (m *Bounded[K, V]) Exists(key K) bool {
+	m.Lock()
+	defer m.Unlock()
+	_, ok := m.data[key]
+	return ok
+}
+
+func (m *Bounded[K, V]) Set(key K, value V) {
+	m.Lock()
+	evicted := m.set(key, value)
+	m.Unlock()
+	m.notify(evicted)
+}
+
+// set puts the entry, and returns the evicted entries for the callback. The
+// lock must be held by caller.
+func (m *Bounded[K, V]) set(key K, value V) []Entry[K, V] {
+	cost := 1
+	if m.costFn != nil {
+		cost = m.costFn(key, value)
+	}
+	if m.capacity > 0 && cost > m.capacity {
+		// It never fits, so it's evicted immediately instead of flushing the
+		// others.
+		m.delete(key)
+		m.stats.Evictions++
+		if m.onEvict != nil {
+			return []Entry[K, V]{{key, value}}
+		}
+		return nil
+	}
+	if e, ok := m.data[key]; ok {
+		m.size += cost - e.cost
+		e.value, e.cost = value, cost
+		m.policy.access(key)
+	} else {
+		m.data[key] = &boundedEntry[V]{value, cost}
+		m.size += cost
+		m.policy.add(key)
+	}
+	return m.evict()
+}
+
+func (m *Bounded[K, V]) Delete(key K) {
+	m.Lock()
+	defer m.Unlock()
+	m.delete(key)
+}
+
+func (m *Bounded[K, V]) delete(key K) {
+	if e, ok := m.data[key]; ok {
+		m.size -= e.cost
+		delete(m.data, key)
+		m.policy.remove(key)
+	}
+}
+
+// GetOrCreate is the same as Map.GetOrCreate(), except the created value may
+// be evicted later.
+func (m *Bounded[K, V]) GetOrCreate(key K, createFn func() V) V {
+	v, _ := m.GetOrCreateE(key, func() (V, error) {
+		return createFn(), nil
+	})
+	return v
+}
+
+// GetOrCreateE is the same as Map.GetOrCreateE(), except the created value may
+// be evicted later.
+func (m *Bounded[K, V]) GetOrCreateE(key K, createFn func() (V, error)) (V, error) {
+	var evicted []Entry[K, V]
+	v, err := createOnce(&m.Mutex, &m.calls, key, func() (V, bool) {
+		return m.load(key)
+	}, func(v V) V {
+		if e, ok := m.data[key]; ok {
+			return e.value
+		}
+		evicted = m.set(key, v)
+		return v
+	}, createFn)
+	m.notify(evicted)
+	return v, err
+}
+
+// evict removes the entries by policy until the capacity is met. The lock must
+// be held by caller.
+func (m *Bounded[K, V]) evict() []Entry[K, V] {
+	if m.capacity <= 0 {
+		return nil
+	}
+	var evicted []Entry[K, V]
+	for m.size > m.capacity {
+		key, ok := m.policy.victim()
+		if !ok {
+			break
+		}
+		e := m.data[key]
+		m.size -= e.cost
+		delete(m.data, key)
+		m.stats.Evictions++
+		if m.onEvict != nil {
+			evicted = append(evicted, Entry[K, V]{key, e.value})
+		}
+	}
+	return evicted
+}
+
+func (m *Bounded[K, V]) notify(evicted []Entry[K, V]) {
+	for _, e := range evicted {
+		m.onEvict(e.Key, e.Value)
+	}
+}
+
+// SetCapacity changes the capacity, and evicts the exceeded entries if any.
+func (m *Bounded[K, V]) SetCapacity(capacity int) {
+	m.Lock()
+	m.capacity = capacity
+	evicted := m.evict()
+	m.Unlock()
+	m.notify(evicted)
+}
+
+func (m *Bounded[K, V]) Capacity() int {
+	m.Lock()
+	defer m.Unlock()
+	return m.capacity
+}
+
+// Size returns the number of entries, or the sum of the costs if WithCost() is
+// given.
+func (m *Bounded[K, V]) Size() int {
+	m.Lock()
+	defer m.Unlock()
+	return m.size
+}
+
+// Stats returns a *copy* of the statistics since created.
+func (m *Bounded[K, V]) Stats() CacheStats {
+	m.Lock()
+	defer m.Unlock()
+	return m.stats
+}
+
+// GetKeys returns all the *copy* of keys, from the last to the first to be
+// evicted, e.g. from the most recently used to the least recently used for
+// LRU.
+func (m *Bounded[K, V]) GetKeys() []K {
+	m.Lock()
+	defer m.Unlock()
+	return m.policy.keys()
+}
+
+// Len returns the number of entries.
+func (m *Bounded[K, V]) Len() int {
+	m.Lock()
+	defer m.Unlock()
+	return len(m.data)
+}
@@ -0,0 +1,102 @@
+package gomap
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestBoundedPeek(t *testing.T) {
+	m := NewBounded[string, int](EvictLRU, 2)
+	m.Set("a", 1)
+	m.Set("b", 2)
+	if v, ok := m.Peek("a"); !ok || v != 1 {
+		t.Errorf("Peek returns %d, %v", v, ok)
+	}
+	m.Set("c", 3)
+	if m.Exists("a") {
+		t.Error("Peek promotes the entry")
+	}
+	if stats := m.Stats(); stats.Hits != 0 || stats.Misses != 0 {
+		t.Errorf("Peek counts in stats %+v", stats)
+	}
+}
+
+func TestBoundedLFU(t *testing.T) {
+	m := NewBounded[string, int](EvictLFU, 3)
+	m.Set("a", 1)
+	m.Set("b", 2)
+	m.Set("c", 3)
+	m.Get("a")
+	m.Get("a")
+	m.Get("c")
+	m.Set("d", 4)
+	if m.Exists("b") || !m.Exists("a") {
+		t.Error("Least frequently used entry not evicted", m.GetKeys())
+	}
+	if !reflect.DeepEqual(m.GetKeys(), []string{"a", "c", "d"}) {
+		t.Error("Unexpected keys order", m.GetKeys())
+	}
+	// c and d have the same frequency, where d is the more recently used.
+	m.Get("d")
+	m.SetCapacity(2)
+	if !reflect.DeepEqual(m.GetKeys(), []string{"a", "d"}) {
+		t.Error("Least recently used entry among the same frequency not evicted", m.GetKeys())
+	}
+}
+
+func TestBoundedARCScan(t *testing.T) {
+	m := NewBounded[string, int](EvictARC, 10)
+	for i := 0; i < 5; i++ {
+		key := "hot" + strconv.Itoa(i)
+		m.Set(key, i)
+		m.Get(key)
+	}
+	// A one-time scan should not flush the frequently used entries.
+	for i := 0; i < 100; i++ {
+		m.Set("scan"+strconv.Itoa(i), i)
+	}
+	for i := 0; i < 5; i++ {
+		if !m.Exists("hot" + strconv.Itoa(i)) {
+			t.Errorf("Hot entry %d flushed by scan", i)
+		}
+	}
+	if m.Len() != 10 {
+		t.Errorf("Len %d exceeds the capacity", m.Len())
+	}
+}
+
+func TestBoundedCost(t *testing.T) {
+	var evicted []string
+	var m *Bounded[string, string]
+	m = NewBounded[string, string](EvictLRU, 10,
+		WithCost(func(key string, value string) int {
+			return len(value)
+		}),
+		WithOnEvict(func(key string, value string) {
+			// Accessing the map in callback should not deadlock.
+			m.Exists(key)
+			evicted = append(evicted, key)
+		}))
+	m.Set("a", "12345")
+	m.Set("b", "1234")
+	if m.Size() != 9 || len(evicted) != 0 {
+		t.Errorf("Unexpected size %d", m.Size())
+	}
+	m.Set("c", "12")
+	if m.Size() != 6 || !reflect.DeepEqual(evicted, []string{"a"}) {
+		t.Errorf("Unexpected size %d and evicted %v", m.Size(), evicted)
+	}
+	m.Set("b", "1")
+	if m.Size() != 3 {
+		t.Errorf("Size %d not updated by the new cost", m.Size())
+	}
+	m.Set("huge", "12345678901")
+	if m.Exists("huge") || m.Size() != 3 || m.Len() != 2 {
+		t.Error("Entry costing more than capacity is kept, or flushes the others")
+	}
+	m.Delete("b")
+	if m.Size() != 2 || !reflect.DeepEqual(evicted, []string{"a", "huge"}) {
+		t.Errorf("Unexpected size %d and evicted %v after delete", m.Size(), evicted)
+	}
+}
@@ -0,0 +1,301 @@
+package gomap
+
+import (
+	"container/heap"
+	"container/list"
+	"sort"
+)
+
+// evictor tracks the usage of keys in a Bounded map, and picks the victim to
+// evict. It's guarded by the lock of the map.
+type evictor[K comparable] interface {
+	// add is called once a new key is set.
+	add(key K)
+	// access is called once an existing key is read or set.
+	access(key K)
+	// remove is called once a key is deleted.
+	remove(key K)
+	// victim removes and returns the key to evict, or false if empty.
+	victim() (K, bool)
+	// keys returns the keys from the last to the first to evict.
+	keys() []K
+}
+
+// keyList is a list of keys with random access, in the order from the most
+// recently pushed to the least.
+type keyList[K comparable] struct {
+	ll    *list.List
+	elems map[K]*list.Element
+}
+
+func newKeyList[K comparable]() *keyList[K] {
+	return &keyList[K]{
+		ll:    list.New(),
+		elems: map[K]*list.Element{},
+	}
+}
+
+func (l *keyList[K]) has(key K) bool {
+	_, ok := l.elems[key]
+	return ok
+}
+
+func (l *keyList[K]) pushFront(key K) {
+	l.elems[key] = l.ll.PushFront(key)
+}
+
+func (l *keyList[K]) moveToFront(key K) bool {
+	e, ok := l.elems[key]
+	if ok {
+		l.ll.MoveToFront(e)
+	}
+	return ok
+}
+
+func (l *keyList[K]) remove(key K) bool {
+	e, ok := l.elems[key]
+	if ok {
+		l.ll.Remove(e)
+		delete(l.elems, key)
+	}
+	return ok
+}
+
+func (l *keyList[K]) removeBack() (K, bool) {
+	e := l.ll.Back()
+	if e == nil {
+		var zero K
+		return zero, false
+	}
+	key := e.Value.(K)
+	l.ll.Remove(e)
+	delete(l.elems, key)
+	return key, true
+}
+
+func (l *keyList[K]) len() int {
+	return l.ll.Len()
+}
+
+func (l *keyList[K]) appendKeys(ret []K) []K {
+	for e := l.ll.Front(); e != nil; e = e.Next() {
+		ret = append(ret, e.Value.(K))
+	}
+	return ret
+}
+
+type lruEvictor[K comparable] struct {
+	*keyList[K]
+}
+
+func newLRUEvictor[K comparable]() *lruEvictor[K] {
+	return &lruEvictor[K]{newKeyList[K]()}
+}
+
+func (e *lruEvictor[K]) add(key K) {
+	e.pushFront(key)
+}
+
+func (e *lruEvictor[K]) access(key K) {
+	e.moveToFront(key)
+}
+
+func (e *lruEvictor[K]) remove(key K) {
+	e.keyList.remove(key)
+}
+
+func (e *lruEvictor[K]) victim() (K, bool) {
+	return e.removeBack()
+}
+
+func (e *lruEvictor[K]) keys() []K {
+	return e.appendKeys(make([]K, 0, e.len()))
+}
+
+type lfuItem[K comparable] struct {
+	key   K
+	freq  int
+	seq   int64
+	index int
+}
+
+// lfuHeap is a min-heap by the frequency, and then by the sequence of the last
+// access.
+type lfuHeap[K comparable] []*lfuItem[K]
+
+func (h lfuHeap[K]) Len() int { return len(h) }
+
+func (h lfuHeap[K]) Less(i, j int) bool {
+	if h[i].freq != h[j].freq {
+		return h[i].freq < h[j].freq
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h lfuHeap[K]) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *lfuHeap[K]) Push(x interface{}) {
+	item := x.(*lfuItem[K])
+	item.index = len(*h)
+	*h = append(*h, item)
+}
+
+func (h *lfuHeap[K]) Pop() interface{} {
+	old := *h
+	item := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return item
+}
+
+type lfuEvictor[K comparable] struct {
+	heap  lfuHeap[K]
+	items map[K]*lfuItem[K]
+	seq   int64
+}
+
+func newLFUEvictor[K comparable]() *lfuEvictor[K] {
+	return &lfuEvictor[K]{
+		items: map[K]*lfuItem[K]{},
+	}
+}
+
+func (e *lfuEvictor[K]) add(key K) {
+	e.seq++
+	item := &lfuItem[K]{key: key, freq: 1, seq: e.seq}
+	e.items[key] = item
+	heap.Push(&e.heap, item)
+}
+
+func (e *lfuEvictor[K]) access(key K) {
+	if item, ok := e.items[key]; ok {
+		e.seq++
+		item.freq++
+		item.seq = e.seq
+		heap.Fix(&e.heap, item.index)
+	}
+}
+
+func (e *lfuEvictor[K]) remove(key K) {
+	if item, ok := e.items[key]; ok {
+		heap.Remove(&e.heap, item.index)
+		delete(e.items, key)
+	}
+}
+
+func (e *lfuEvictor[K]) victim() (K, bool) {
+	if len(e.heap) == 0 {
+		var zero K
+		return zero, false
+	}
+	item := heap.Pop(&e.heap).(*lfuItem[K])
+	delete(e.items, item.key)
+	return item.key, true
+}
+
+func (e *lfuEvictor[K]) keys() []K {
+	items := make(lfuHeap[K], len(e.heap))
+	copy(items, e.heap)
+	sort.Slice(items, func(i, j int) bool {
+		return items[j].freq < items[i].freq ||
+			items[j].freq == items[i].freq && items[j].seq < items[i].seq
+	})
+	ret := make([]K, len(items))
+	for i, item := range items {
+		ret[i] = item.key
+	}
+	return ret
+}
+
+// arcEvictor implements the adaptive replacement cache. Live keys are kept in
+// t1 if seen once recently, or t2 if seen at least twice. The keys evicted from
+// them are remembered in the ghost lists b1 and b2, and a hit in the ghost
+// lists adapts the target size p of t1.
+type arcEvictor[K comparable] struct {
+	t1, t2, b1, b2 *keyList[K]
+	p              int
+}
+
+func newARCEvictor[K comparable]() *arcEvictor[K] {
+	return &arcEvictor[K]{
+		t1: newKeyList[K](),
+		t2: newKeyList[K](),
+		b1: newKeyList[K](),
+		b2: newKeyList[K](),
+	}
+}
+
+func (e *arcEvictor[K]) add(key K) {
+	switch {
+	case e.b1.remove(key):
+		// Recently evicted for recency, so t1 deserves more room.
+		delta := 1
+		if e.b1.len() > 0 && e.b2.len()/e.b1.len() > delta {
+			delta = e.b2.len() / e.b1.len()
+		}
+		e.p += delta
+		if live := e.t1.len() + e.t2.len() + 1; e.p > live {
+			e.p = live
+		}
+		e.t2.pushFront(key)
+	case e.b2.remove(key):
+		delta := 1
+		if e.b2.len() > 0 && e.b1.len()/e.b2.len() > delta {
+			delta = e.b1.len() / e.b2.len()
+		}
+		e.p -= delta
+		if e.p < 0 {
+			e.p = 0
+		}
+		e.t2.pushFront(key)
+	default:
+		e.t1.pushFront(key)
+	}
+}
+
+func (e *arcEvictor[K]) access(key K) {
+	if e.t1.remove(key) {
+		e.t2.pushFront(key)
+		return
+	}
+	e.t2.moveToFront(key)
+}
+
+func (e *arcEvictor[K]) remove(key K) {
+	if !e.t1.remove(key) {
+		e.t2.remove(key)
+	}
+}
+
+func (e *arcEvictor[K]) victim() (K, bool) {
+	var key K
+	var ok bool
+	if e.t1.len() > 0 && (e.t1.len() > e.p || e.t2.len() == 0) {
+		key, ok = e.t1.removeBack()
+		e.b1.pushFront(key)
+	} else if key, ok = e.t2.removeBack(); ok {
+		e.b2.pushFront(key)
+	}
+
+	// The ghost lists remember at most as many keys as the live ones.
+	limit := e.t1.len() + e.t2.len()
+	if limit < 1 {
+		limit = 1
+	}
+	for e.b1.len() > limit {
+		e.b1.removeBack()
+	}
+	for e.b2.len() > limit {
+		e.b2.removeBack()
+	}
+	return key, ok
+}
+
+func (e *arcEvictor[K]) keys() []K {
+	ret := make([]K, 0, e.t1.len()+e.t2.len())
+	return e.t1.appendKeys(e.t2.appendKeys(ret))
+}
@@ -0,0 +1,11 @@
+package gomap
+
+// LRU is a Bounded map with string keys, evicting the least recently used
+// entry once the capacity is exceeded.
+type LRU = Bounded[string, interface{}]
+
+// NewLRU creates a new LRU structure holding at most capacity entries.
+// A non-positive capacity means no bound.
+func NewLRU(capacity int) *LRU {
+	return NewBounded[string, interface{}](EvictLRU, capacity)
+}
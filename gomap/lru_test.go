@@ -0,0 +1,71 @@
+package gomap
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestLRUEviction(t *testing.T) {
+	m := NewLRU(2)
+	m.Set("a", 1)
+	m.Set("b", 2)
+	if m.Get("a").(int) != 1 {
+		t.Error("Get not correct")
+	}
+	m.Set("c", 3)
+	if m.Exists("b") {
+		t.Error("Least recently used entry not evicted")
+	}
+	if !reflect.DeepEqual(m.GetKeys(), []string{"c", "a"}) {
+		t.Error("Unexpected keys order", m.GetKeys())
+	}
+	if m.Get("b") != nil {
+		t.Error("Get returns evicted entry")
+	}
+
+	stats := m.Stats()
+	if stats.Hits != 1 || stats.Misses != 1 || stats.Evictions != 1 {
+		t.Error("Unexpected stats", stats)
+	}
+
+	m.SetCapacity(1)
+	if m.Len() != 1 || !m.Exists("c") {
+		t.Error("Shrinking capacity not evicting", m.GetKeys())
+	}
+}
+
+func TestLRUUnbounded(t *testing.T) {
+	m := NewLRU(0)
+	for i := 0; i < 100; i++ {
+		m.Set(string(rune('a'+i)), i)
+	}
+	if m.Len() != 100 {
+		t.Error("Unbounded LRU evicted entries")
+	}
+	m.Delete("a")
+	if m.Len() != 99 || m.Exists("a") {
+		t.Error("Delete not taking effect")
+	}
+}
+
+func TestLRUGetOrCreateE(t *testing.T) {
+	m := NewLRU(2)
+	if _, err := m.GetOrCreateE("a", func() (interface{}, error) {
+		return nil, errors.New("failed")
+	}); err == nil || m.Exists("a") {
+		t.Error("GetOrCreateE caches the failure")
+	}
+	m.GetOrCreate("a", func() interface{} { return 1 })
+	m.GetOrCreate("b", func() interface{} { return 2 })
+	if v := m.GetOrCreate("a", func() interface{} { return 10 }); v.(int) != 1 {
+		t.Errorf("GetOrCreate returns %v, expect the cached 1", v)
+	}
+	m.GetOrCreate("c", func() interface{} { return 3 })
+	if m.Exists("b") || m.Len() != 2 {
+		t.Error("GetOrCreate not evicting the least recently used")
+	}
+	if stats := m.Stats(); stats.Hits != 1 || stats.Misses != 4 || stats.Evictions != 1 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+}
@@ -0,0 +1,295 @@
+package gomap
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// MultiMap is a thread-safe key-value structure mapping each key to multiple
+// values, e.g. the index from tags to ids. Values of a key are kept in the
+// order of insertion.
+// Example usage:
+//   index := gomap.NewMultiMap[string, int64]()
+//   for _, tag := range doc.Tags {
+//       index.Add(tag, doc.Id)
+//   }
+//   ids := index.Get("golang")
+type MultiMap[K comparable, V comparable] struct {
+	sync.RWMutex
+	data map[K][]V
+	// sets indexes the values of each key, which ignores the duplicated values
+	// of the same key. It's nil for list multi maps.
+	sets map[K]map[V]struct{}
+}
+
+// NewMultiMap creates a new multi map, whose values of the same key are a set,
+// i.e. adding an existing value has no effect. Add() and Contains() take O(1)
+// time, while RemoveValue() takes O(n) time to keep the order of values.
+func NewMultiMap[K comparable, V comparable]() *MultiMap[K, V] {
+	return &MultiMap[K, V]{
+		data: map[K][]V{},
+		sets: map[K]map[V]struct{}{},
+	}
+}
+
+// NewListMultiMap creates a new multi map, whose values of the same key are a
+// list, i.e. duplicated values are kept.
+func NewListMultiMap[K comparable, V comparable]() *MultiMap[K, V] {
+	return WrapMultiMap[K, V](nil)
+}
+
+// WrapMultiMap takes the ownership of a built-in map, and return a new list
+// multi map structure.
+func WrapMultiMap[K comparable, V comparable](m map[K][]V) *MultiMap[K, V] {
+	if m == nil {
+		m = map[K][]V{}
+	}
+	for k, values := range m {
+		if len(values) == 0 {
+			delete(m, k)
+		}
+	}
+	return &MultiMap[K, V]{
+		data: m,
+	}
+}
+
+// Unwrap releases the ownership of the inner built-in map, and return it. The
+// same as Map, the map structure won't function any more.
+func (m *MultiMap[K, V]) Unwrap() map[K][]V {
+	m.Lock()
+	defer m.Unlock()
+
+	d := m.data
+	m.data = nil
+	m.sets = nil
+	return d
+}
+
+// Clone copies the keys and values to a new map structure.
+func (m *MultiMap[K, V]) Clone() *MultiMap[K, V] {
+	m.RLock()
+	defer m.RUnlock()
+
+	ret := &MultiMap[K, V]{
+		data: make(map[K][]V, len(m.data)),
+	}
+	if m.sets != nil {
+		ret.sets = make(map[K]map[V]struct{}, len(m.sets))
+	}
+	for k, values := range m.data {
+		ret.add(k, values)
+	}
+	return ret
+}
+
+// MarshalJSON implements the json.Marshaller interface.
+func (m *MultiMap[K, V]) MarshalJSON() ([]byte, error) {
+	m.RLock()
+	defer m.RUnlock()
+	return json.Marshal(m.data)
+}
+
+// UnmarshalJSON implements the json.Unmarshaller interface. The values are
+// added to the existing ones.
+func (m *MultiMap[K, V]) UnmarshalJSON(d []byte) error {
+	var data map[K][]V
+	if err := json.Unmarshal(d, &data); err != nil {
+		return err
+	}
+	m.Lock()
+	defer m.Unlock()
+	if m.data == nil {
+		m.data = map[K][]V{}
+	}
+	for k, values := range data {
+		m.add(k, values)
+	}
+	return nil
+}
+
+// GobEncode implements the gob.GobEncoder interface, see Save().
+func (m *MultiMap[K, V]) GobEncode() ([]byte, error) {
+	m.RLock()
+	defer m.RUnlock()
+	return gobEncode(m.data)
+}
+
+// GobDecode implements the gob.GobDecoder interface, see Load(). It replaces
+// the content of the map.
+func (m *MultiMap[K, V]) GobDecode(d []byte) error {
+	var data map[K][]V
+	if err := gobDecode(d, &data); err != nil {
+		return err
+	}
+	m.Lock()
+	defer m.Unlock()
+	m.data = make(map[K][]V, len(data))
+	if m.sets != nil {
+		m.sets = make(map[K]map[V]struct{}, len(data))
+	}
+	for k, values := range data {
+		m.add(k, values)
+	}
+	return nil
+}
+
+// Add appends the values to the key.
+func (m *MultiMap[K, V]) Add(key K, values ...V) {
+	m.Lock()
+	defer m.Unlock()
+	m.add(key, values)
+}
+
+func (m *MultiMap[K, V]) add(key K, values []V) {
+	if len(values) == 0 {
+		return
+	}
+	if m.sets == nil {
+		m.data[key] = append(m.data[key], values...)
+		return
+	}
+	set := m.sets[key]
+	if set == nil {
+		set = make(map[V]struct{}, len(values))
+		m.sets[key] = set
+	}
+	for _, v := range values {
+		if _, exists := set[v]; exists {
+			continue
+		}
+		set[v] = struct{}{}
+		m.data[key] = append(m.data[key], v)
+	}
+}
+
+// Set replaces all the values of the key.
+func (m *MultiMap[K, V]) Set(key K, values ...V) {
+	m.Lock()
+	defer m.Unlock()
+	m.delete(key)
+	m.add(key, values)
+}
+
+func (m *MultiMap[K, V]) delete(key K) {
+	delete(m.data, key)
+	if m.sets != nil {
+		delete(m.sets, key)
+	}
+}
+
+// Get returns the *copy* of values of the key.
+func (m *MultiMap[K, V]) Get(key K) []V {
+	m.RLock()
+	defer m.RUnlock()
+	return append([]V(nil), m.data[key]...)
+}
+
+// Contains reports whether the value is one of the values of the key.
+func (m *MultiMap[K, V]) Contains(key K, value V) bool {
+	m.RLock()
+	defer m.RUnlock()
+	if m.sets != nil {
+		_, ok := m.sets[key][value]
+		return ok
+	}
+	return indexOf(m.data[key], value) >= 0
+}
+
+// Count returns the number of values of the key.
+func (m *MultiMap[K, V]) Count(key K) int {
+	m.RLock()
+	defer m.RUnlock()
+	return len(m.data[key])
+}
+
+// RemoveValue removes the first occurrence of the value from the key, and
+// reports whether it's found. The key is deleted once it has no value.
+func (m *MultiMap[K, V]) RemoveValue(key K, value V) bool {
+	m.Lock()
+	defer m.Unlock()
+	if m.sets != nil {
+		if _, ok := m.sets[key][value]; !ok {
+			return false
+		}
+		delete(m.sets[key], value)
+	}
+	values := m.data[key]
+	i := indexOf(values, value)
+	if i < 0 {
+		return false
+	}
+	if len(values) == 1 {
+		m.delete(key)
+		return true
+	}
+	m.data[key] = append(values[:i:i], values[i+1:]...)
+	return true
+}
+
+// Delete removes the key with all its values.
+func (m *MultiMap[K, V]) Delete(key K) {
+	m.Lock()
+	m.delete(key)
+	m.Unlock()
+}
+
+// Exists reports whether the key has any value.
+func (m *MultiMap[K, V]) Exists(key K) bool {
+	m.RLock()
+	defer m.RUnlock()
+	_, ok := m.data[key]
+	return ok
+}
+
+// Len returns the number of keys.
+func (m *MultiMap[K, V]) Len() int {
+	m.RLock()
+	defer m.RUnlock()
+	return len(m.data)
+}
+
+// Size returns the number of values of all the keys.
+func (m *MultiMap[K, V]) Size() int {
+	m.RLock()
+	defer m.RUnlock()
+	n := 0
+	for _, values := range m.data {
+		n += len(values)
+	}
+	return n
+}
+
+// GetKeys returns all the *copy* of keys in the same order of Map.GetKeys().
+func (m *MultiMap[K, V]) GetKeys() []K {
+	m.RLock()
+	ret := make([]K, 0, len(m.data))
+	for k := range m.data {
+		ret = append(ret, k)
+	}
+	m.RUnlock()
+	sortKeys(ret)
+	return ret
+}
+
+// GetItems returns all the *copy* of keys and values, and sort them by keys in
+// the same order of GetKeys().
+func (m *MultiMap[K, V]) GetItems() []Entry[K, []V] {
+	m.RLock()
+	ret := make([]Entry[K, []V], 0, len(m.data))
+	for k, values := range m.data {
+		ret = append(ret, Entry[K, []V]{k, append([]V(nil), values...)})
+	}
+	m.RUnlock()
+	sortEntries(ret)
+	return ret
+}
+
+func indexOf[V comparable](values []V, value V) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+	return -1
+}
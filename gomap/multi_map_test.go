@@ -0,0 +1,80 @@
+package gomap
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestMultiMap(t *testing.T) {
+	m := NewMultiMap[string, int]()
+	m.Add("a", 1, 2, 1)
+	m.Add("b", 3)
+	m.Add("a", 2, 4)
+	if !reflect.DeepEqual(m.Get("a"), []int{1, 2, 4}) || m.Count("a") != 3 {
+		t.Errorf("Get returns %v, duplicated values not ignored", m.Get("a"))
+	}
+	if !m.Contains("a", 4) || m.Contains("b", 4) {
+		t.Error("Contains not correct")
+	}
+	if !m.RemoveValue("a", 2) || m.RemoveValue("a", 2) || !reflect.DeepEqual(m.Get("a"), []int{1, 4}) {
+		t.Errorf("RemoveValue leaves %v", m.Get("a"))
+	}
+	if !m.RemoveValue("b", 3) || m.Exists("b") {
+		t.Error("Key not deleted once empty")
+	}
+	if m.Len() != 1 || m.Size() != 2 {
+		t.Errorf("Unexpected len %d and size %d", m.Len(), m.Size())
+	}
+	m.Add("a", 2)
+	m.Set("b", 5, 5)
+	if !reflect.DeepEqual(m.Get("a"), []int{1, 4, 2}) || !reflect.DeepEqual(m.Get("b"), []int{5}) {
+		t.Errorf("Values not re-added: %v", m.GetItems())
+	}
+
+	clone := m.Clone()
+	clone.Add("a", 1, 6)
+	if !reflect.DeepEqual(clone.Get("a"), []int{1, 4, 2, 6}) || m.Contains("a", 6) {
+		t.Errorf("Clone of set multi map: %v", clone.GetItems())
+	}
+	d, err := m.GobEncode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := NewMultiMap[string, int]()
+	if err := decoded.GobDecode(d); err != nil {
+		t.Fatal(err)
+	}
+	decoded.Add("b", 5)
+	if !reflect.DeepEqual(decoded.GetItems(), m.GetItems()) || !decoded.Contains("a", 4) {
+		t.Errorf("GobDecode returns %v", decoded.GetItems())
+	}
+
+	list := NewListMultiMap[string, int]()
+	list.Add("b", 1, 1)
+	list.Add("a", 2)
+	items := list.GetItems()
+	if len(items) != 2 || items[0].Key != "a" || !reflect.DeepEqual(items[1].Value, []int{1, 1}) {
+		t.Errorf("GetItems returns %v", items)
+	}
+}
+
+func TestMultiMapJSON(t *testing.T) {
+	m := WrapMultiMap(map[string][]int{"a": {1, 2}, "b": nil})
+	if m.Exists("b") {
+		t.Error("Empty key is kept")
+	}
+	clone := m.Clone()
+	clone.Add("a", 3)
+	if m.Count("a") != 2 {
+		t.Error("Clone refers to the old memory")
+	}
+	d, err := json.Marshal(clone)
+	if err != nil || string(d) != `{"a":[1,2,3]}` {
+		t.Errorf("Marshal returns %s, %v", d, err)
+	}
+	decoded := NewMultiMap[string, int]()
+	if err := json.Unmarshal(d, decoded); err != nil || !reflect.DeepEqual(decoded.Unwrap(), clone.Unwrap()) {
+		t.Errorf("Unmarshal not correct, %v", err)
+	}
+}
@@ -0,0 +1,56 @@
+package gomap
+
+import (
+	"sort"
+)
+
+// Number is the constraint of the values in NumberMap.
+type Number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 |
+		~float32 | ~float64
+}
+
+// NumberMap is a map with numeric values, which can be accumulated.
+type NumberMap[K comparable, V Number] struct {
+	Of[K, V]
+}
+
+// NewNumberMap creates a new map structure.
+func NewNumberMap[K comparable, V Number]() *NumberMap[K, V] {
+	return WrapNumberMap[K, V](nil)
+}
+
+// WrapNumberMap takes the ownership of a built-in map, and return a new map
+// structure.
+func WrapNumberMap[K comparable, V Number](m map[K]V) *NumberMap[K, V] {
+	if m == nil {
+		m = map[K]V{}
+	}
+	return &NumberMap[K, V]{Of[K, V]{data: m}}
+}
+
+// Clone shallow copies the keys and values to a new map structure.
+func (m *NumberMap[K, V]) Clone() *NumberMap[K, V] {
+	return WrapNumberMap(m.cloneData())
+}
+
+// Add accumulates the value to the key.
+func (m *NumberMap[K, V]) Add(key K, value V) {
+	m.Lock()
+	m.put(key, m.data[key]+value)
+	m.Unlock()
+}
+
+// GetTopN returns top N entries in desc value order.
+func (m *NumberMap[K, V]) GetTopN(n int) []Entry[K, V] {
+	items := m.GetItemsUnordered()
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Value > items[j].Value
+	})
+
+	if n > len(items) {
+		n = len(items)
+	}
+	return items[:n]
+}
@@ -0,0 +1,457 @@
+package gomap
+
+import (
+	"encoding/json"
+	"fmt"
+	"iter"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// Of is a thread-safe key-value structure just like the built-in map, with
+// typed keys and values. Map, StringMap, IntMap and FloatMap are all based
+// on it.
+// Example usage:
+//   m := gomap.NewOf[string, *User]()
+//   m.Set(user.Id, user)
+//   for id, user := range m.All() {
+//       ...
+//   }
+type Of[K comparable, V any] struct {
+	sync.RWMutex
+	data  map[K]V
+	calls map[K]*call[V]
+	// journal records the changes for Persister, with the lock held.
+	journal func(key K, value V, deleted bool)
+}
+
+// Entry is the return structure for iterating.
+type Entry[K comparable, V any] struct {
+	Key   K
+	Value V
+}
+
+// NewOf creates a new map structure.
+func NewOf[K comparable, V any]() *Of[K, V] {
+	return &Of[K, V]{
+		data: map[K]V{},
+	}
+}
+
+// WrapOf takes the ownership of a built-in map, and return a new map structure.
+func WrapOf[K comparable, V any](m map[K]V) *Of[K, V] {
+	if m == nil {
+		return NewOf[K, V]()
+	}
+	return &Of[K, V]{
+		data: m,
+	}
+}
+
+// Unwrap releases the ownership of the inner built-in map, and return it.
+// Note that the map structure also remove the reference to this map object,
+// which means this map structure won't function any more but in the ease of
+// the risk of memory leak.
+func (m *Of[K, V]) Unwrap() map[K]V {
+	m.Lock()
+	defer m.Unlock()
+
+	d := m.data
+	m.data = nil
+	return d
+}
+
+// Clone shallow copies the keys and values to a new map structure.
+func (m *Of[K, V]) Clone() *Of[K, V] {
+	return WrapOf(m.cloneData())
+}
+
+func (m *Of[K, V]) cloneData() map[K]V {
+	m.RLock()
+	defer m.RUnlock()
+
+	newData := make(map[K]V, len(m.data))
+	for k, v := range m.data {
+		newData[k] = v
+	}
+	return newData
+}
+
+// MarshalJSON implements the json.Marshaller interface.
+func (m *Of[K, V]) MarshalJSON() ([]byte, error) {
+	m.RLock()
+	defer m.RUnlock()
+	d, err := json.Marshal(m.data)
+	return d, err
+}
+
+// UnmarshalJSON implements the json.Unmarshaller interface.
+func (m *Of[K, V]) UnmarshalJSON(d []byte) error {
+	var data map[K]V
+	if err := json.Unmarshal(d, &data); err != nil {
+		return err
+	}
+	m.Lock()
+	defer m.Unlock()
+	if m.data == nil {
+		m.data = map[K]V{}
+	}
+	for k, v := range data {
+		m.put(k, v)
+	}
+	return nil
+}
+
+// GobEncode implements the gob.GobEncoder interface, see Save().
+func (m *Of[K, V]) GobEncode() ([]byte, error) {
+	m.RLock()
+	defer m.RUnlock()
+	return gobEncode(m.data)
+}
+
+// GobDecode implements the gob.GobDecoder interface, see Load(). It replaces
+// the content of the map.
+func (m *Of[K, V]) GobDecode(d []byte) error {
+	var data map[K]V
+	if err := gobDecode(d, &data); err != nil {
+		return err
+	}
+	m.Lock()
+	defer m.Unlock()
+	for k := range m.data {
+		if _, ok := data[k]; !ok {
+			m.remove(k)
+		}
+	}
+	if m.data == nil {
+		m.data = make(map[K]V, len(data))
+	}
+	for k, v := range data {
+		m.put(k, v)
+	}
+	return nil
+}
+
+// put sets the value, with the lock held.
+func (m *Of[K, V]) put(key K, value V) {
+	m.data[key] = value
+	if m.journal != nil {
+		m.journal(key, value, false)
+	}
+}
+
+// remove deletes the key, with the lock held.
+func (m *Of[K, V]) remove(key K) {
+	delete(m.data, key)
+	if m.journal != nil {
+		var zero V
+		m.journal(key, zero, true)
+	}
+}
+
+func (m *Of[K, V]) Set(key K, value V) {
+	m.Lock()
+	m.put(key, value)
+	m.Unlock()
+}
+
+func (m *Of[K, V]) Delete(key K) {
+	m.Lock()
+	m.remove(key)
+	m.Unlock()
+}
+
+// Get returns the value by key, or the zero value if not exists.
+func (m *Of[K, V]) Get(key K) V {
+	m.RLock()
+	defer m.RUnlock()
+	return m.data[key]
+}
+
+// Load is the same as Get(), except it reports whether the key exists.
+func (m *Of[K, V]) Load(key K) (V, bool) {
+	m.RLock()
+	defer m.RUnlock()
+	v, ok := m.data[key]
+	return v, ok
+}
+
+func (m *Of[K, V]) Exists(key K) bool {
+	m.RLock()
+	defer m.RUnlock()
+	_, ok := m.data[key]
+	return ok
+}
+
+// GetOrCreate gets the value by key. If no value exists, it will call the createFn() to generate a new object.
+// It's useful to implement a singleton cache flow, where you only
+// want to create each key/value exactly once.
+// createFn is called without holding the lock, so creations of different keys
+// run in parallel, and concurrent callers of the same key wait for the single
+// creation. createFn may access the map, except creating the same key. If
+// createFn panics, the waiting callers panic as well.
+func (m *Of[K, V]) GetOrCreate(key K, createFn func() V) V {
+	v, _ := m.GetOrCreateE(key, func() (V, error) {
+		return createFn(), nil
+	})
+	return v
+}
+
+// GetOrCreateE is the same as GetOrCreate(), except createFn may fail. The
+// error is returned to the callers waiting for the same creation, and the next
+// call will try to create again.
+// Example usage:
+//   client, err := clients.GetOrCreateE(addr, func() (*Client, error) {
+//       return dial(addr)
+//   })
+func (m *Of[K, V]) GetOrCreateE(key K, createFn func() (V, error)) (V, error) {
+	m.RLock()
+	if v, ok := m.data[key]; ok {
+		m.RUnlock()
+		return v, nil
+	}
+	m.RUnlock()
+
+	return createOnce(&m.RWMutex, &m.calls, key, func() (V, bool) {
+		v, ok := m.data[key]
+		return v, ok
+	}, func(v V) V {
+		// Keep the value set by others in between.
+		if old, ok := m.data[key]; ok {
+			return old
+		}
+		m.put(key, v)
+		return v
+	}, createFn)
+}
+
+// Update sets the value by fn atomically. fn receives the current value and
+// whether it exists, and returns the new value.
+// IMPORTANT NOTE: The fn should not invoke any method in this map, otherwise
+// it will DEADLOCK.
+// Example usage:
+//   m.Update("visits", func(v int, exists bool) int {
+//       return v + 1
+//   })
+func (m *Of[K, V]) Update(key K, fn func(value V, exists bool) V) V {
+	m.Lock()
+	defer m.Unlock()
+	v, ok := m.data[key]
+	v = fn(v, ok)
+	m.put(key, v)
+	return v
+}
+
+// CompareAndSwap swaps the value of key to new, if the current value equals
+// to old. It panics if the values are not comparable, the same as sync.Map.
+func (m *Of[K, V]) CompareAndSwap(key K, old, new V) bool {
+	m.Lock()
+	defer m.Unlock()
+	v, ok := m.data[key]
+	if !ok || any(v) != any(old) {
+		return false
+	}
+	m.put(key, new)
+	return true
+}
+
+// LoadAndDelete deletes the key, and returns the previous value if any.
+func (m *Of[K, V]) LoadAndDelete(key K) (V, bool) {
+	m.Lock()
+	defer m.Unlock()
+	v, ok := m.data[key]
+	if ok {
+		m.remove(key)
+	}
+	return v, ok
+}
+
+// Range calls fn for each key/value pair until fn returns false. It iterates
+// over a snapshot, so fn may modify the map.
+func (m *Of[K, V]) Range(fn func(key K, value V) bool) {
+	for _, e := range m.GetItemsUnordered() {
+		if !fn(e.Key, e.Value) {
+			return
+		}
+	}
+}
+
+// All returns an iterator over the key/value pairs in a snapshot, with no
+// order guaranteed.
+func (m *Of[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		m.Range(yield)
+	}
+}
+
+// Keys returns an iterator over the keys in a snapshot, with no order
+// guaranteed.
+func (m *Of[K, V]) Keys() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for _, k := range m.GetKeysUnordered() {
+			if !yield(k) {
+				return
+			}
+		}
+	}
+}
+
+// Values returns an iterator over the values in a snapshot, with no order
+// guaranteed.
+func (m *Of[K, V]) Values() iter.Seq[V] {
+	return func(yield func(V) bool) {
+		for _, v := range m.GetValues() {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// GetKeysUnordered returns all the *copy* of keys, but no order is guaranteed.
+func (m *Of[K, V]) GetKeysUnordered() []K {
+	return m.appendKeys(nil)
+}
+
+func (m *Of[K, V]) appendKeys(ret []K) []K {
+	m.RLock()
+	defer m.RUnlock()
+
+	if ret == nil {
+		ret = make([]K, 0, len(m.data))
+	}
+	for k := range m.data {
+		ret = append(ret, k)
+	}
+	return ret
+}
+
+// GetKeys returns all the *copy* of keys in ascending order. Keys of types
+// other than strings and numbers are sorted by their formatted strings.
+func (m *Of[K, V]) GetKeys() []K {
+	keys := m.GetKeysUnordered()
+	sortKeys(keys)
+	return keys
+}
+
+// GetValues returns all the *copy* of values, but no order is guaranteed.
+func (m *Of[K, V]) GetValues() []V {
+	return m.appendValues(nil)
+}
+
+func (m *Of[K, V]) appendValues(ret []V) []V {
+	m.RLock()
+	defer m.RUnlock()
+
+	if ret == nil {
+		ret = make([]V, 0, len(m.data))
+	}
+	for _, v := range m.data {
+		ret = append(ret, v)
+	}
+	return ret
+}
+
+// GetItemsUnordered returns all the *copy* of key/value pairs, but no order is guaranteed.
+func (m *Of[K, V]) GetItemsUnordered() []Entry[K, V] {
+	return m.appendItems(nil)
+}
+
+func (m *Of[K, V]) appendItems(ret []Entry[K, V]) []Entry[K, V] {
+	m.RLock()
+	defer m.RUnlock()
+
+	if ret == nil {
+		ret = make([]Entry[K, V], 0, len(m.data))
+	}
+	for k, v := range m.data {
+		ret = append(ret, Entry[K, V]{
+			Key:   k,
+			Value: v,
+		})
+	}
+	return ret
+}
+
+// GetItems returns all the *copy* of key/value pairs, and sort them by keys
+// in the same order of GetKeys().
+func (m *Of[K, V]) GetItems() []Entry[K, V] {
+	items := m.GetItemsUnordered()
+	sortEntries(items)
+	return items
+}
+
+// Len returns the size of the map.
+func (m *Of[K, V]) Len() int {
+	m.RLock()
+	defer m.RUnlock()
+	return len(m.data)
+}
+
+func sortKeys[K comparable](keys []K) {
+	sort.Slice(keys, func(i, j int) bool {
+		return lessKey(keys[i], keys[j])
+	})
+}
+
+func sortEntries[K comparable, V any](items []Entry[K, V]) {
+	sort.Slice(items, func(i, j int) bool {
+		return lessKey(items[i].Key, items[j].Key)
+	})
+}
+
+// lessKey orders strings and numbers naturally, including the named types like
+// `type ID int64`. Keys of other kinds are ordered by their formatted strings.
+func lessKey[K comparable](a, b K) bool {
+	// Fast path for the common key types.
+	switch x := any(a).(type) {
+	case string:
+		if y, ok := any(b).(string); ok {
+			return x < y
+		}
+	case int:
+		if y, ok := any(b).(int); ok {
+			return x < y
+		}
+	case int64:
+		if y, ok := any(b).(int64); ok {
+			return x < y
+		}
+	}
+
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	if !va.IsValid() || !vb.IsValid() {
+		// nil interfaces go first.
+		return !va.IsValid() && vb.IsValid()
+	}
+	ka, kb := orderedKind(va.Kind()), orderedKind(vb.Kind())
+	if ka != kb {
+		// Keys of interface types may mix kinds, which are grouped by kind.
+		return ka < kb
+	}
+	switch ka {
+	case reflect.String:
+		return va.String() < vb.String()
+	case reflect.Int:
+		return va.Int() < vb.Int()
+	case reflect.Uint:
+		return va.Uint() < vb.Uint()
+	case reflect.Float64:
+		return va.Float() < vb.Float()
+	}
+	return fmt.Sprint(a) < fmt.Sprint(b)
+}
+
+// orderedKind merges the kinds compared in the same way, e.g. all the ints.
+func orderedKind(k reflect.Kind) reflect.Kind {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return reflect.Int
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return reflect.Uint
+	case reflect.Float32, reflect.Float64:
+		return reflect.Float64
+	}
+	return k
+}
@@ -0,0 +1,239 @@
+package gomap
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestOfUpdate(t *testing.T) {
+	m := NewOf[string, int]()
+	for i := 0; i < 3; i++ {
+		m.Update("visits", func(v int, exists bool) int {
+			if exists != (i > 0) {
+				t.Errorf("Update reports exists %v at round %d", exists, i)
+			}
+			return v + 1
+		})
+	}
+	if m.Get("visits") != 3 {
+		t.Errorf("Update results %d, expect 3", m.Get("visits"))
+	}
+}
+
+func TestOfCompareAndSwap(t *testing.T) {
+	m := WrapOf(map[int]string{1: "a"})
+	if m.CompareAndSwap(1, "b", "c") || m.Get(1) != "a" {
+		t.Error("CompareAndSwap swaps unmatched value")
+	}
+	if m.CompareAndSwap(2, "", "c") || m.Exists(2) {
+		t.Error("CompareAndSwap swaps non-exists key")
+	}
+	if !m.CompareAndSwap(1, "a", "c") || m.Get(1) != "c" {
+		t.Error("CompareAndSwap not taking effect")
+	}
+}
+
+func TestOfLoadAndDelete(t *testing.T) {
+	m := WrapOf(map[string]int{"a": 1})
+	if v, ok := m.LoadAndDelete("a"); !ok || v != 1 {
+		t.Errorf("LoadAndDelete returns %d, %v", v, ok)
+	}
+	if _, ok := m.LoadAndDelete("a"); ok || m.Len() != 0 {
+		t.Error("LoadAndDelete not taking effect")
+	}
+}
+
+func TestOfIterators(t *testing.T) {
+	m := WrapOf(map[int]string{3: "c", 1: "a", 2: "b"})
+	got := map[int]string{}
+	for k, v := range m.All() {
+		got[k] = v
+		// Modifying in iterating should not deadlock.
+		m.Set(k*10, v)
+	}
+	if len(got) != 3 || got[1] != "a" || got[3] != "c" {
+		t.Errorf("All returns %v", got)
+	}
+
+	count := 0
+	m.Range(func(k int, v string) bool {
+		count++
+		return false
+	})
+	if count != 1 {
+		t.Errorf("Range not stopping, called %d times", count)
+	}
+
+	var keys []int
+	for k := range m.Keys() {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	if len(keys) != 6 || keys[0] != 1 || keys[5] != 30 {
+		t.Errorf("Keys returns %v", keys)
+	}
+
+	var values []string
+	for v := range m.Values() {
+		values = append(values, v)
+	}
+	if len(values) != 6 {
+		t.Errorf("Values returns %v", values)
+	}
+}
+
+func TestOfGetKeys(t *testing.T) {
+	m := WrapOf(map[int]bool{10: true, 2: true, 1: true})
+	keys := m.GetKeys()
+	if keys[0] != 1 || keys[1] != 2 || keys[2] != 10 {
+		t.Errorf("GetKeys not returning in numeric order: %v", keys)
+	}
+
+	type id int64
+	ids := WrapOf(map[id]bool{10: true, 2: true, -1: true, 9: true})
+	if keys := ids.GetKeys(); !reflect.DeepEqual(keys, []id{-1, 2, 9, 10}) {
+		t.Errorf("GetKeys of named ints = %v", keys)
+	}
+	int8s := WrapOf(map[int8]bool{10: true, 2: true, -1: true})
+	if keys := int8s.GetKeys(); !reflect.DeepEqual(keys, []int8{-1, 2, 10}) {
+		t.Errorf("GetKeys of int8 = %v", keys)
+	}
+	floats := WrapOf(map[float32]bool{10: true, 2.5: true, -1: true})
+	if keys := floats.GetKeys(); !reflect.DeepEqual(keys, []float32{-1, 2.5, 10}) {
+		t.Errorf("GetKeys of float32 = %v", keys)
+	}
+	mixed := WrapOf(map[interface{}]bool{10: true, 9: true, "b": true, "a": true})
+	if keys := mixed.GetKeys(); !reflect.DeepEqual(keys, []interface{}{9, 10, "a", "b"}) {
+		t.Errorf("GetKeys of mixed kinds = %v", keys)
+	}
+}
+
+func TestOfJSON(t *testing.T) {
+	m := NewOf[string, int]()
+	if err := json.Unmarshal([]byte(`{"a":1,"b":2}`), m); err != nil {
+		t.Fatal(err)
+	}
+	d, err := json.Marshal(m)
+	if err != nil || string(d) != `{"a":1,"b":2}` {
+		t.Errorf("Marshal returns %s, %v", d, err)
+	}
+}
+
+func TestIntMapTopN(t *testing.T) {
+	m := NewIntMap()
+	m.Add("a", 1)
+	m.Add("b", 3)
+	m.Add("b", 2)
+	m.Add("c", 4)
+	top := m.GetTopN(2)
+	if len(top) != 2 || top[0].Key != "b" || top[0].Value != 5 || top[1].Key != "c" {
+		t.Errorf("GetTopN returns %v", top)
+	}
+	if len(m.Clone().GetTopN(10)) != 3 {
+		t.Error("GetTopN not capped by size")
+	}
+}
+
+func TestOfGetOrCreateSingleFlight(t *testing.T) {
+	m := NewOf[string, int]()
+	var created int32
+	start := make(chan struct{})
+	wg := sync.WaitGroup{}
+	wg.Add(10)
+	for i := 0; i < 10; i++ {
+		go func() {
+			defer wg.Done()
+			v := m.GetOrCreate("key", func() int {
+				atomic.AddInt32(&created, 1)
+				<-start
+				return 1
+			})
+			if v != 1 {
+				t.Errorf("GetOrCreate returns %d, expect 1", v)
+			}
+		}()
+	}
+	time.Sleep(time.Millisecond * 10)
+
+	// Other keys and methods are not blocked by the creation.
+	m.Set("other", 2)
+	if v := m.GetOrCreate("another", func() int { return m.Get("other") + 1 }); v != 3 {
+		t.Errorf("GetOrCreate returns %d, expect 3", v)
+	}
+	close(start)
+	wg.Wait()
+	if created != 1 {
+		t.Errorf("createFn is called %d times, expect 1", created)
+	}
+}
+
+func TestOfGetOrCreateE(t *testing.T) {
+	m := NewOf[string, int]()
+	_, err := m.GetOrCreateE("key", func() (int, error) {
+		return 0, errors.New("failed")
+	})
+	if err == nil || m.Exists("key") {
+		t.Error("GetOrCreateE caches the failure")
+	}
+	v, err := m.GetOrCreateE("key", func() (int, error) {
+		return 1, nil
+	})
+	if err != nil || v != 1 || m.Get("key") != 1 {
+		t.Errorf("GetOrCreateE returns %d, %v", v, err)
+	}
+}
+
+func TestOfGetOrCreatePanic(t *testing.T) {
+	m := NewOf[string, *int]()
+	start := make(chan struct{})
+	waiting := make(chan struct{})
+	var waiter interface{}
+	go func() {
+		defer close(waiting)
+		defer func() {
+			waiter = recover()
+		}()
+		<-start
+		m.GetOrCreate("key", func() *int {
+			t.Error("Waiter creates again")
+			return nil
+		})
+	}()
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("Panic is swallowed")
+			}
+		}()
+		m.GetOrCreate("key", func() *int {
+			close(start)
+			time.Sleep(time.Millisecond * 10)
+			panic("failed")
+		})
+	}()
+	<-waiting
+	if waiter != "failed" {
+		t.Errorf("Waiter recovers %v", waiter)
+	}
+	one := 1
+	if v := m.GetOrCreate("key", func() *int { return &one }); v != &one {
+		t.Errorf("GetOrCreate after panic returns %v", v)
+	}
+}
+
+func TestOfGetOrCreateSetInBetween(t *testing.T) {
+	m := NewOf[string, int]()
+	v := m.GetOrCreate("key", func() int {
+		m.Set("key", 2)
+		return 1
+	})
+	if v != 2 || m.Get("key") != 2 {
+		t.Errorf("GetOrCreate returns %d, but keeps %d", v, m.Get("key"))
+	}
+}
@@ -0,0 +1,291 @@
+package gomap
+
+import (
+	"iter"
+	"sync"
+)
+
+// OrderedMap is a thread-safe key-value structure which preserves the order of
+// insertion. Setting an existing key keeps its position. The positions are
+// indexed by a skip list, so Rank() and At() are in O(log n) as well.
+// Example usage:
+//   m := gomap.NewOrderedMap[string, *Step]()
+//   m.Set("fetch", fetch)
+//   m.Set("parse", parse)
+//   for name, step := range m.IterFrom("parse") {
+//       ...
+//   }
+type OrderedMap[K comparable, V any] struct {
+	sync.RWMutex
+	index map[K]*orderedItem[K, V]
+	list  *skipList[*orderedItem[K, V]]
+	seq   int64
+}
+
+type orderedItem[K comparable, V any] struct {
+	seq int64
+	Entry[K, V]
+}
+
+// NewOrderedMap creates a new map structure.
+func NewOrderedMap[K comparable, V any]() *OrderedMap[K, V] {
+	return &OrderedMap[K, V]{
+		index: map[K]*orderedItem[K, V]{},
+		list: newSkipList(func(a, b *orderedItem[K, V]) int {
+			switch {
+			case a.seq < b.seq:
+				return -1
+			case a.seq > b.seq:
+				return 1
+			}
+			return 0
+		}),
+	}
+}
+
+// GobEncode implements the gob.GobEncoder interface, see Save(). The order
+// is preserved.
+func (m *OrderedMap[K, V]) GobEncode() ([]byte, error) {
+	return gobEncode(m.GetItems())
+}
+
+// GobDecode implements the gob.GobDecoder interface, see Load(). It replaces
+// the content of the map.
+func (m *OrderedMap[K, V]) GobDecode(d []byte) error {
+	var items []Entry[K, V]
+	if err := gobDecode(d, &items); err != nil {
+		return err
+	}
+	m.Lock()
+	defer m.Unlock()
+	m.index = make(map[K]*orderedItem[K, V], len(items))
+	m.list = newSkipList(m.list.cmp)
+	for _, e := range items {
+		if item, ok := m.index[e.Key]; ok {
+			item.Value = e.Value
+			continue
+		}
+		m.seq++
+		item := &orderedItem[K, V]{m.seq, e}
+		m.index[e.Key] = item
+		m.list.insert(item)
+	}
+	return nil
+}
+
+// Set updates the value of an existing key in place, or appends the key to
+// the end.
+func (m *OrderedMap[K, V]) Set(key K, value V) {
+	m.Lock()
+	defer m.Unlock()
+	if item, ok := m.index[key]; ok {
+		item.Value = value
+		return
+	}
+	m.seq++
+	item := &orderedItem[K, V]{m.seq, Entry[K, V]{key, value}}
+	m.index[key] = item
+	m.list.insert(item)
+}
+
+func (m *OrderedMap[K, V]) Delete(key K) {
+	m.LoadAndDelete(key)
+}
+
+// LoadAndDelete deletes the key, and returns the previous value if any.
+func (m *OrderedMap[K, V]) LoadAndDelete(key K) (V, bool) {
+	m.Lock()
+	defer m.Unlock()
+	item, ok := m.index[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	delete(m.index, key)
+	m.list.remove(item)
+	return item.Value, true
+}
+
+// Get returns the value by key, or the zero value if not exists.
+func (m *OrderedMap[K, V]) Get(key K) V {
+	v, _ := m.Load(key)
+	return v
+}
+
+// Load is the same as Get(), except it reports whether the key exists.
+func (m *OrderedMap[K, V]) Load(key K) (V, bool) {
+	m.RLock()
+	defer m.RUnlock()
+	if item, ok := m.index[key]; ok {
+		return item.Value, true
+	}
+	var zero V
+	return zero, false
+}
+
+func (m *OrderedMap[K, V]) Exists(key K) bool {
+	m.RLock()
+	defer m.RUnlock()
+	_, ok := m.index[key]
+	return ok
+}
+
+// Len returns the size of the map.
+func (m *OrderedMap[K, V]) Len() int {
+	m.RLock()
+	defer m.RUnlock()
+	return len(m.index)
+}
+
+// GetKeys returns all the *copy* of keys in the order of insertion.
+func (m *OrderedMap[K, V]) GetKeys() []K {
+	items := m.GetItems()
+	ret := make([]K, len(items))
+	for i, e := range items {
+		ret[i] = e.Key
+	}
+	return ret
+}
+
+// GetValues returns all the *copy* of values in the order of insertion.
+func (m *OrderedMap[K, V]) GetValues() []V {
+	items := m.GetItems()
+	ret := make([]V, len(items))
+	for i, e := range items {
+		ret[i] = e.Value
+	}
+	return ret
+}
+
+// GetItems returns all the *copy* of key/value pairs in the order of insertion.
+func (m *OrderedMap[K, V]) GetItems() []Entry[K, V] {
+	m.RLock()
+	defer m.RUnlock()
+	return entries(m.list.collect(m.list.head.next[0], -1))
+}
+
+// Front returns the earliest inserted entry.
+func (m *OrderedMap[K, V]) Front() (Entry[K, V], bool) {
+	return m.At(0)
+}
+
+// Back returns the latest inserted entry.
+func (m *OrderedMap[K, V]) Back() (Entry[K, V], bool) {
+	m.RLock()
+	defer m.RUnlock()
+	return itemEntry(m.list.at(m.list.length - 1))
+}
+
+// Rank returns the 0-based position of the key in the order of insertion.
+func (m *OrderedMap[K, V]) Rank(key K) (int, bool) {
+	m.RLock()
+	defer m.RUnlock()
+	item, ok := m.index[key]
+	if !ok {
+		return 0, false
+	}
+	return m.list.rank(item)
+}
+
+// At returns the entry at the 0-based position in the order of insertion.
+func (m *OrderedMap[K, V]) At(rank int) (Entry[K, V], bool) {
+	m.RLock()
+	defer m.RUnlock()
+	return itemEntry(m.list.at(rank))
+}
+
+// Prev returns the entry inserted right before the key.
+func (m *OrderedMap[K, V]) Prev(key K) (Entry[K, V], bool) {
+	m.RLock()
+	defer m.RUnlock()
+	item, ok := m.index[key]
+	if !ok {
+		return Entry[K, V]{}, false
+	}
+	return itemEntry(m.list.floor(&orderedItem[K, V]{seq: item.seq - 1}))
+}
+
+// Next returns the entry inserted right after the key.
+func (m *OrderedMap[K, V]) Next(key K) (Entry[K, V], bool) {
+	m.RLock()
+	defer m.RUnlock()
+	item, ok := m.index[key]
+	if !ok {
+		return Entry[K, V]{}, false
+	}
+	return itemEntry(m.list.seek(item, false))
+}
+
+// Between returns the *copy* of entries from the key from to the key to
+// inclusively, in the order of insertion. It returns nil if any of the keys
+// doesn't exist, or from is inserted after to.
+func (m *OrderedMap[K, V]) Between(from, to K) []Entry[K, V] {
+	m.RLock()
+	defer m.RUnlock()
+	start, ok := m.index[from]
+	end, ok2 := m.index[to]
+	if !ok || !ok2 {
+		return nil
+	}
+	var ret []Entry[K, V]
+	for x := m.list.find(start); x != nil && x.item.seq <= end.seq; x = x.next[0] {
+		ret = append(ret, x.item.Entry)
+	}
+	return ret
+}
+
+// All returns an iterator over the key/value pairs in the order of insertion.
+// The map is not locked while running the loop body, so it may modify the map.
+func (m *OrderedMap[K, V]) All() iter.Seq2[K, V] {
+	return m.iter(nil)
+}
+
+// IterFrom returns an iterator over the key/value pairs in the order of
+// insertion, starting from the key. It yields nothing if the key doesn't
+// exist.
+func (m *OrderedMap[K, V]) IterFrom(key K) iter.Seq2[K, V] {
+	return m.iter(&key)
+}
+
+func (m *OrderedMap[K, V]) iter(from *K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		iterate(func(last *orderedItem[K, V]) []orderedItem[K, V] {
+			m.RLock()
+			defer m.RUnlock()
+			start := m.list.head.next[0]
+			if last != nil {
+				start = m.list.seek(last, false)
+			} else if from != nil {
+				item, ok := m.index[*from]
+				if !ok {
+					return nil
+				}
+				start = m.list.find(item)
+			}
+			// Values are copied, since they may be updated in place.
+			items := m.list.collect(start, iterBatch)
+			ret := make([]orderedItem[K, V], len(items))
+			for i, item := range items {
+				ret[i] = *item
+			}
+			return ret
+		}, func(item orderedItem[K, V]) bool {
+			return yield(item.Key, item.Value)
+		})
+	}
+}
+
+func entries[K comparable, V any](items []*orderedItem[K, V]) []Entry[K, V] {
+	ret := make([]Entry[K, V], len(items))
+	for i, item := range items {
+		ret[i] = item.Entry
+	}
+	return ret
+}
+
+func itemEntry[K comparable, V any](n *skipNode[*orderedItem[K, V]]) (Entry[K, V], bool) {
+	if n == nil {
+		return Entry[K, V]{}, false
+	}
+	return n.item.Entry, true
+}
@@ -0,0 +1,73 @@
+package gomap
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestOrderedMap(t *testing.T) {
+	m := NewOrderedMap[string, int]()
+	for _, k := range []string{"c", "a", "d", "b"} {
+		m.Set(k, len(k))
+	}
+	m.Set("a", 10)
+	m.Delete("d")
+	m.Set("d", 4)
+	if !reflect.DeepEqual(m.GetKeys(), []string{"c", "a", "b", "d"}) {
+		t.Errorf("GetKeys returns %v", m.GetKeys())
+	}
+	if m.Get("a") != 10 {
+		t.Error("Set not updating in place")
+	}
+	if rank, ok := m.Rank("b"); !ok || rank != 2 {
+		t.Errorf("Rank returns %d", rank)
+	}
+	if e, ok := m.At(1); !ok || e.Key != "a" {
+		t.Errorf("At returns %v", e)
+	}
+	if e, _ := m.Front(); e.Key != "c" {
+		t.Errorf("Front returns %v", e)
+	}
+	if e, _ := m.Back(); e.Key != "d" {
+		t.Errorf("Back returns %v", e)
+	}
+	if e, ok := m.Prev("b"); !ok || e.Key != "a" {
+		t.Errorf("Prev returns %v", e)
+	}
+	if _, ok := m.Prev("c"); ok {
+		t.Error("Prev of the front returns something")
+	}
+	if e, ok := m.Next("b"); !ok || e.Key != "d" {
+		t.Errorf("Next returns %v", e)
+	}
+	between := m.Between("a", "b")
+	if len(between) != 2 || between[0].Key != "a" || between[1].Key != "b" {
+		t.Errorf("Between returns %v", between)
+	}
+	if m.Between("b", "a") != nil {
+		t.Error("Between in reversed order returns something")
+	}
+}
+
+func TestOrderedMapIter(t *testing.T) {
+	m := NewOrderedMap[string, int]()
+	for i := 0; i < 200; i++ {
+		m.Set(strconv.Itoa(i), i)
+	}
+	var values []int
+	for _, v := range m.IterFrom("100") {
+		values = append(values, v)
+		// Modifying in iterating should not deadlock.
+		m.Set(strconv.Itoa(v), -v)
+	}
+	if len(values) != 100 || values[0] != 100 || values[99] != 199 {
+		t.Errorf("IterFrom returns %d values from %d", len(values), values[0])
+	}
+	for range m.IterFrom("missing") {
+		t.Error("IterFrom yields for non-exists key")
+	}
+	if m.Get("150") != -150 {
+		t.Error("Set in iterating not taking effect")
+	}
+}
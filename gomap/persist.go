@@ -0,0 +1,446 @@
+package gomap
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/gob"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Save writes the snapshot of the container to the file in gob, e.g. Map,
+// SortedMap and Set. The file is written to a temp file and then renamed, so
+// it's never left half written.
+// NOTE: Concrete types stored in interface{} values must be registered by
+// gob.Register().
+func Save(path string, v gob.GobEncoder) error {
+	d, err := v.GobEncode()
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, d)
+}
+
+// Load replaces the content of the container by the snapshot written by
+// Save().
+func Load(path string, v gob.GobDecoder) error {
+	d, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return v.GobDecode(d)
+}
+
+func gobEncode(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func gobDecode(d []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(d)).Decode(v)
+}
+
+func writeFileAtomic(path string, d []byte) error {
+	f, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(d); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		return err
+	}
+	// The rename itself is only durable once the directory is synced.
+	return syncDir(filepath.Dir(path))
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+type persistConfig struct {
+	snapshotInterval time.Duration
+	flushInterval    time.Duration
+	onError          func(error)
+}
+
+// PersistOption customizes the Persister.
+type PersistOption func(*persistConfig)
+
+// WithSnapshotInterval takes snapshots in background periodically, which
+// truncates the change log. By default, the snapshot is only taken by Close()
+// or Snapshot().
+func WithSnapshotInterval(d time.Duration) PersistOption {
+	return func(c *persistConfig) {
+		c.snapshotInterval = d
+	}
+}
+
+// WithFlushInterval changes the interval to flush the change log to disk,
+// which is 1s by default. Changes within the interval may be lost on crash.
+func WithFlushInterval(d time.Duration) PersistOption {
+	return func(c *persistConfig) {
+		c.flushInterval = d
+	}
+}
+
+// WithPersistErrorHandler handles the errors in background, which are logged
+// to stderr by default.
+func WithPersistErrorHandler(fn func(error)) PersistOption {
+	return func(c *persistConfig) {
+		c.onError = fn
+	}
+}
+
+// Persister keeps a map on disk, by snapshots and an append-only log of the
+// changes in between. The snapshot is at path, and the logs are at path.log.N,
+// where N increases on every snapshot.
+// Changes made by any methods of the map are logged, so the map can be used as
+// usual.
+// Example usage:
+//   counts := gomap.NewIntMap()
+//   p, err := gomap.Persist(&counts.Of, "counts.snap", gomap.WithSnapshotInterval(time.Minute))
+//   if err != nil {
+//       goutils.LogFatal(err)
+//   }
+//   defer p.Close()
+//   for _, word := range words {
+//       counts.Add(word, 1)
+//   }
+type Persister[K comparable, V any] struct {
+	persistConfig
+	m    *Of[K, V]
+	path string
+
+	snapshotLock sync.Mutex
+	// gen is the generation of the current log, guarded by snapshotLock.
+	gen int
+
+	lock    sync.Mutex
+	logFile *os.File
+	logBuf  *bufio.Writer
+	encoder *gob.Encoder
+	err     error
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// persistRecord is an entry of the change log. The value after the change is
+// recorded, instead of the delta, so replaying the log is idempotent.
+type persistRecord[K comparable, V any] struct {
+	Key     K
+	Value   V
+	Deleted bool
+}
+
+// Persist restores the map from the snapshot and the log at path if any,
+// replacing its content, and then logs the changes of the map until Close().
+// A map should be persisted by at most one Persister.
+func Persist[K comparable, V any](m *Of[K, V], path string, opts ...PersistOption) (*Persister[K, V], error) {
+	p := &Persister[K, V]{
+		persistConfig: persistConfig{
+			flushInterval: time.Second,
+			onError: func(err error) {
+				log.Println("[ERROR] Persist map:", err)
+			},
+		},
+		m:    m,
+		path: path,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(&p.persistConfig)
+	}
+
+	if err := Load(path, m); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	// There are multiple logs if crashed or failed in the middle of a
+	// snapshot, see Snapshot().
+	gens, err := p.logGens()
+	if err != nil {
+		return nil, err
+	}
+	for _, gen := range gens {
+		if err := p.replay(p.logPath(gen)); err != nil {
+			return nil, err
+		}
+	}
+
+	// The restored content is saved before writing any new log, so that each
+	// log is written by a single gob encoder.
+	d, err := m.GobEncode()
+	if err != nil {
+		return nil, err
+	}
+	if err := writeFileAtomic(path, d); err != nil {
+		return nil, err
+	}
+	for _, gen := range gens {
+		if err := os.Remove(p.logPath(gen)); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		p.gen = gen
+	}
+
+	m.Lock()
+	m.journal = p.record
+	m.Unlock()
+	if err := p.Snapshot(); err != nil {
+		p.detach()
+		p.lock.Lock()
+		p.closeLog()
+		p.lock.Unlock()
+		return nil, err
+	}
+
+	go p.run()
+	return p, nil
+}
+
+func (p *Persister[K, V]) logPath(gen int) string {
+	return p.path + ".log." + strconv.Itoa(gen)
+}
+
+// logGens returns the generations of the logs on disk in ascending order.
+func (p *Persister[K, V]) logGens() ([]int, error) {
+	entries, err := os.ReadDir(filepath.Dir(p.path))
+	if err != nil {
+		return nil, err
+	}
+	prefix := filepath.Base(p.path) + ".log."
+	var gens []int
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name(), prefix) {
+			continue
+		}
+		if gen, err := strconv.Atoi(strings.TrimPrefix(entry.Name(), prefix)); err == nil {
+			gens = append(gens, gen)
+		}
+	}
+	sort.Ints(gens)
+	return gens, nil
+}
+
+// replay applies the changes in the log. The tail of the log may be broken on
+// crash, which is ignored.
+func (p *Persister[K, V]) replay(logPath string) error {
+	f, err := os.Open(logPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	p.m.Lock()
+	defer p.m.Unlock()
+	if p.m.data == nil {
+		p.m.data = map[K]V{}
+	}
+	decoder := gob.NewDecoder(bufio.NewReader(f))
+	for {
+		var r persistRecord[K, V]
+		if err := decoder.Decode(&r); err != nil {
+			if err != io.EOF {
+				p.onError(err)
+			}
+			return nil
+		}
+		if r.Deleted {
+			delete(p.m.data, r.Key)
+		} else {
+			p.m.data[r.Key] = r.Value
+		}
+	}
+}
+
+// record appends the change to the log, with the lock of the map held.
+func (p *Persister[K, V]) record(key K, value V, deleted bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.encoder == nil || p.err != nil {
+		return
+	}
+	p.err = p.encoder.Encode(persistRecord[K, V]{key, value, deleted})
+}
+
+// Snapshot writes the current content to the snapshot, and truncates the log.
+func (p *Persister[K, V]) Snapshot() error {
+	p.snapshotLock.Lock()
+	defer p.snapshotLock.Unlock()
+
+	// Changes are switched to the log of the next generation while copying
+	// the content, so that the map is not blocked by writing the snapshot. The
+	// old logs are kept until the snapshot is written, even if this snapshot
+	// fails and the next one switches again. Since the logs are idempotent,
+	// replaying them over either the old or the new snapshot gives the same
+	// result.
+	p.gen++
+	next, err := os.OpenFile(p.logPath(p.gen), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	if err := syncDir(filepath.Dir(p.path)); err != nil {
+		next.Close()
+		os.Remove(next.Name())
+		return err
+	}
+	p.m.Lock()
+	d, err := gobEncode(p.m.data)
+	if err != nil {
+		p.m.Unlock()
+		next.Close()
+		os.Remove(next.Name())
+		return err
+	}
+	p.lock.Lock()
+	oldFile, oldBuf, oldErr := p.logFile, p.logBuf, p.err
+	p.logFile = next
+	p.logBuf = bufio.NewWriter(next)
+	p.encoder = gob.NewEncoder(p.logBuf)
+	p.err = nil
+	p.lock.Unlock()
+	p.m.Unlock()
+
+	if oldFile != nil {
+		if oldErr == nil {
+			oldErr = oldBuf.Flush()
+		}
+		oldFile.Close()
+		if oldErr != nil {
+			// Changes in the old log may be lost, which are in the snapshot
+			// anyway.
+			p.onError(oldErr)
+		}
+	}
+	if err := writeFileAtomic(p.path, d); err != nil {
+		return err
+	}
+	gens, err := p.logGens()
+	if err != nil {
+		return err
+	}
+	for _, gen := range gens {
+		if gen >= p.gen {
+			break
+		}
+		if err := os.Remove(p.logPath(gen)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// closeLog flushes and closes the current log, with the lock held.
+func (p *Persister[K, V]) closeLog() error {
+	if p.logFile == nil {
+		return nil
+	}
+	err := p.flush()
+	if closeErr := p.logFile.Close(); err == nil {
+		err = closeErr
+	}
+	p.logFile, p.logBuf, p.encoder = nil, nil, nil
+	return err
+}
+
+// flush writes the buffered changes to disk, with the lock held.
+func (p *Persister[K, V]) flush() error {
+	if p.err != nil {
+		return p.err
+	}
+	if err := p.logBuf.Flush(); err != nil {
+		return err
+	}
+	return p.logFile.Sync()
+}
+
+// Flush writes the buffered changes to disk.
+func (p *Persister[K, V]) Flush() error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.logFile == nil {
+		return nil
+	}
+	return p.flush()
+}
+
+func (p *Persister[K, V]) run() {
+	defer close(p.done)
+	flush := time.NewTicker(p.flushInterval)
+	defer flush.Stop()
+	var snapshot <-chan time.Time
+	if p.snapshotInterval > 0 {
+		ticker := time.NewTicker(p.snapshotInterval)
+		defer ticker.Stop()
+		snapshot = ticker.C
+	}
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-flush.C:
+			if err := p.Flush(); err != nil {
+				p.onError(err)
+			}
+		case <-snapshot:
+			if err := p.Snapshot(); err != nil {
+				p.onError(err)
+			}
+		}
+	}
+}
+
+func (p *Persister[K, V]) detach() {
+	p.m.Lock()
+	p.m.journal = nil
+	p.m.Unlock()
+}
+
+// Close takes the final snapshot, and stops logging the changes of the map.
+func (p *Persister[K, V]) Close() error {
+	var err error
+	p.once.Do(func() {
+		close(p.stop)
+		<-p.done
+		err = p.Snapshot()
+		p.detach()
+		p.lock.Lock()
+		if closeErr := p.closeLog(); err == nil {
+			err = closeErr
+		}
+		p.lock.Unlock()
+	})
+	return err
+}
@@ -0,0 +1,200 @@
+package gomap
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSaveLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	m := WrapIntMap(map[string]int{"a": 1, "b": 2})
+	if err := Save(filepath.Join(dir, "int"), m); err != nil {
+		t.Fatal(err)
+	}
+	loaded := WrapIntMap(map[string]int{"c": 3})
+	if err := Load(filepath.Join(dir, "int"), loaded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded.Unwrap(), m.Unwrap()) {
+		t.Error("Load not replacing the content")
+	}
+
+	sorted := NewSortedMap[int, string]()
+	ordered := NewOrderedMap[string, int]()
+	for i, k := range []string{"c", "a", "b"} {
+		sorted.Set(i, k)
+		ordered.Set(k, i)
+	}
+	set := NewSetFromSlice([]int{1, 2})
+	if err := Save(filepath.Join(dir, "sorted"), sorted); err != nil {
+		t.Fatal(err)
+	}
+	if err := Save(filepath.Join(dir, "ordered"), ordered); err != nil {
+		t.Fatal(err)
+	}
+	if err := Save(filepath.Join(dir, "set"), set); err != nil {
+		t.Fatal(err)
+	}
+
+	loadedSorted := NewSortedMap[int, string]()
+	loadedOrdered := NewOrderedMap[string, int]()
+	loadedSet := NewSetOf[int]()
+	for name, v := range map[string]interface {
+		GobDecode([]byte) error
+	}{"sorted": loadedSorted, "ordered": loadedOrdered, "set": loadedSet} {
+		if err := Load(filepath.Join(dir, name), v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !reflect.DeepEqual(loadedSorted.GetItems(), sorted.GetItems()) {
+		t.Errorf("Loaded sorted map %v", loadedSorted.GetItems())
+	}
+	if !reflect.DeepEqual(loadedOrdered.GetKeys(), []string{"c", "a", "b"}) {
+		t.Errorf("Loaded ordered map %v", loadedOrdered.GetKeys())
+	}
+	if !loadedSet.Equal(set) {
+		t.Errorf("Loaded set %v", loadedSet.GetElements())
+	}
+
+	if err := Load(filepath.Join(dir, "missing"), loadedSet); !os.IsNotExist(err) {
+		t.Errorf("Load missing file returns %v", err)
+	}
+}
+
+func TestPersist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "counts")
+
+	counts := NewIntMap()
+	p, err := Persist(&counts.Of, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts.Add("a", 1)
+	counts.Add("a", 2)
+	counts.Set("b", 5)
+	if err := p.Snapshot(); err != nil {
+		t.Fatal(err)
+	}
+	counts.Add("b", 1)
+	counts.Delete("a")
+	counts.Add("c", 1)
+	if err := p.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Restores from the snapshot and the log, as if crashed.
+	restored := NewIntMap()
+	p2, err := Persist(&restored.Of, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]int{"b": 6, "c": 1}
+	if !reflect.DeepEqual(restored.Clone().Unwrap(), expected) {
+		t.Errorf("Restored %v, expect %v", restored.Clone().Unwrap(), expected)
+	}
+
+	restored.Add("c", 1)
+	if err := p2.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// Changes after closing are not persisted.
+	restored.Add("c", 100)
+
+	final := NewIntMap()
+	p3, err := Persist(&final.Of, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p3.Close()
+	if final.Get("c") != 2 || final.Len() != 2 {
+		t.Errorf("Restored %v after close", final.Clone().Unwrap())
+	}
+}
+
+func TestPersistInterruptedSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "map")
+
+	m := NewOf[string, string]()
+	p, err := Persist(m, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Set("a", "1")
+	m.Set("b", "1")
+	p.Flush()
+	// Simulates the crash right after switching to the next log in Snapshot(),
+	// by restoring the snapshot and the logs before it.
+	files, _ := filepath.Glob(path + "*")
+	backup := map[string][]byte{}
+	for _, file := range files {
+		backup[file], _ = os.ReadFile(file)
+	}
+	if err := p.Snapshot(); err != nil {
+		t.Fatal(err)
+	}
+	m.Set("a", "2")
+	p.Flush()
+	for file, d := range backup {
+		os.WriteFile(file, d, 0644)
+	}
+
+	restored := NewOf[string, string]()
+	p2, err := Persist(restored, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p2.Close()
+	if restored.Get("a") != "2" || restored.Get("b") != "1" {
+		t.Errorf("Restored %v", restored.Clone().Unwrap())
+	}
+	if logs, _ := filepath.Glob(path + ".log.*"); len(logs) != 1 {
+		t.Errorf("Old logs are not cleaned up: %v", logs)
+	}
+}
+
+func TestPersistFailedSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "map")
+
+	m := NewOf[string, string]()
+	p, err := Persist(m, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	m.Set("a", "1")
+
+	// Snapshots fail to replace the file by a directory, e.g. as if the disk
+	// is full.
+	os.Rename(path, path+".bak")
+	os.Mkdir(path, 0755)
+	os.WriteFile(filepath.Join(path, "x"), nil, 0644)
+	m.Set("b", "1")
+	if err := p.Snapshot(); err == nil {
+		t.Fatal("No error for failed snapshot")
+	}
+	m.Set("c", "1")
+	if err := p.Snapshot(); err == nil {
+		t.Fatal("No error for failed snapshot")
+	}
+	m.Set("d", "1")
+	if err := p.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Crashes with the old snapshot.
+	os.RemoveAll(path)
+	os.Rename(path+".bak", path)
+	restored := NewOf[string, string]()
+	p2, err := Persist(restored, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p2.Close()
+	expected := map[string]string{"a": "1", "b": "1", "c": "1", "d": "1"}
+	if !reflect.DeepEqual(restored.Clone().Unwrap(), expected) {
+		t.Errorf("Restored %v, expect %v", restored.Clone().Unwrap(), expected)
+	}
+}
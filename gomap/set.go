@@ -1,34 +1,46 @@
 package gomap
 
 import (
+	"bytes"
 	"encoding/json"
-	"sort"
+	"iter"
 	"sync"
 )
 
-// Set is a Map with values as built-in bool type.
-type Set struct {
+// Set is a thread-safe set of comparable elements, with the set algebra like
+// Union() and Intersect(). It's encoded as an array in JSON.
+// Example usage:
+//   seen := gomap.NewSetOf[int64]()
+//   seen.AddAll(ids...)
+//   fresh := gomap.NewSetFromSlice(candidates).Difference(seen)
+type Set[T comparable] struct {
 	sync.RWMutex
-	data map[string]bool
+	data map[T]bool
 }
 
-func NewSet() *Set {
-	return &Set{
-		data: map[string]bool{},
+// NewSet creates a new set of strings.
+func NewSet() *Set[string] {
+	return NewSetOf[string]()
+}
+
+// NewSetOf creates a new set.
+func NewSetOf[T comparable]() *Set[T] {
+	return &Set[T]{
+		data: map[T]bool{},
 	}
 }
 
-func NewSetFromSlice(s []string) *Set {
-	data := map[string]bool{}
+func NewSetFromSlice[T comparable](s []T) *Set[T] {
+	data := make(map[T]bool, len(s))
 	for _, k := range s {
 		data[k] = true
 	}
-	return &Set{
+	return &Set[T]{
 		data: data,
 	}
 }
 
-func (m *Set) removeInvalid() {
+func (m *Set[T]) removeInvalid() {
 	// A book keeper to make sure no invalid (false value) values leaks to outside.
 	for k, v := range m.data {
 		if !v {
@@ -37,18 +49,18 @@ func (m *Set) removeInvalid() {
 	}
 }
 
-func WrapSet(m map[string]bool) *Set {
+func WrapSet[T comparable](m map[T]bool) *Set[T] {
 	if m == nil {
-		return NewSet()
+		return NewSetOf[T]()
 	}
-	s := &Set{
+	s := &Set[T]{
 		data: m,
 	}
 	s.removeInvalid()
 	return s
 }
 
-func (m *Set) Unwrap() map[string]bool {
+func (m *Set[T]) Unwrap() map[T]bool {
 	m.Lock()
 	defer m.Unlock()
 
@@ -57,60 +69,279 @@ func (m *Set) Unwrap() map[string]bool {
 	return d
 }
 
-func (m *Set) Clone() *Set {
-	m.RLock()
-	defer m.RUnlock()
-
-	newData := map[string]bool{}
-	for k, v := range m.data {
-		newData[k] = v
+func (m *Set[T]) Clone() *Set[T] {
+	return &Set[T]{
+		data: m.cloneData(),
 	}
-
-	return WrapSet(newData)
 }
 
-func (m *Set) MarshalJSON() ([]byte, error) {
+func (m *Set[T]) cloneData() map[T]bool {
 	m.RLock()
 	defer m.RUnlock()
-	d, err := json.Marshal(m.data)
 
-	return d, err
+	newData := make(map[T]bool, len(m.data))
+	for k := range m.data {
+		newData[k] = true
+	}
+	return newData
+}
+
+// MarshalJSON implements the json.Marshaller interface. The elements are
+// encoded as an array in the same order of GetElements().
+func (m *Set[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.GetElements())
 }
 
-func (m *Set) UnmarshalJSON(d []byte) error {
+// UnmarshalJSON implements the json.Unmarshaller interface. The legacy object
+// form like {"a": true} is accepted as well.
+func (m *Set[T]) UnmarshalJSON(d []byte) error {
 	m.Lock()
 	defer m.Unlock()
-	if err := json.Unmarshal(d, &m.data); err != nil {
-		return err
-	} else {
+	if m.data == nil {
+		m.data = map[T]bool{}
+	}
+	if d = bytes.TrimSpace(d); len(d) > 0 && d[0] == '{' {
+		if err := json.Unmarshal(d, &m.data); err != nil {
+			return err
+		}
 		m.removeInvalid()
 		return nil
 	}
+
+	var elems []T
+	if err := json.Unmarshal(d, &elems); err != nil {
+		return err
+	}
+	for _, elem := range elems {
+		m.data[elem] = true
+	}
+	return nil
+}
+
+// GobEncode implements the gob.GobEncoder interface, see Save().
+func (m *Set[T]) GobEncode() ([]byte, error) {
+	return gobEncode(m.GetElementsUnordered())
+}
+
+// GobDecode implements the gob.GobDecoder interface, see Load(). It replaces
+// the content of the set.
+func (m *Set[T]) GobDecode(d []byte) error {
+	var elems []T
+	if err := gobDecode(d, &elems); err != nil {
+		return err
+	}
+	data := make(map[T]bool, len(elems))
+	for _, elem := range elems {
+		data[elem] = true
+	}
+	m.Lock()
+	m.data = data
+	m.Unlock()
+	return nil
 }
 
-func (m *Set) Add(elem string) {
+func (m *Set[T]) Add(elem T) {
 	m.Lock()
 	m.data[elem] = true
 	m.Unlock()
 }
 
-func (m *Set) Remove(elem string) {
+// AddAll adds all the elements under a single lock.
+func (m *Set[T]) AddAll(elems ...T) {
+	m.Lock()
+	defer m.Unlock()
+	for _, elem := range elems {
+		m.data[elem] = true
+	}
+}
+
+func (m *Set[T]) Remove(elem T) {
 	m.Lock()
 	delete(m.data, elem)
 	m.Unlock()
 }
 
-func (m *Set) Contains(elem string) bool {
+// RemoveAll removes all the elements under a single lock.
+func (m *Set[T]) RemoveAll(elems ...T) {
+	m.Lock()
+	defer m.Unlock()
+	for _, elem := range elems {
+		delete(m.data, elem)
+	}
+}
+
+// Pop removes and returns an arbitrary element, or false if the set is empty.
+func (m *Set[T]) Pop() (T, bool) {
+	m.Lock()
+	defer m.Unlock()
+	for k := range m.data {
+		delete(m.data, k)
+		return k, true
+	}
+	var zero T
+	return zero, false
+}
+
+func (m *Set[T]) Contains(elem T) bool {
 	m.RLock()
 	defer m.RUnlock()
 	return m.data[elem]
 }
 
-func (m *Set) GetElementsUnordered() []string {
+// ContainsAll reports whether all the elements are in the set.
+func (m *Set[T]) ContainsAll(elems ...T) bool {
+	m.RLock()
+	defer m.RUnlock()
+	for _, elem := range elems {
+		if !m.data[elem] {
+			return false
+		}
+	}
+	return true
+}
+
+// ContainsAny reports whether any of the elements is in the set.
+func (m *Set[T]) ContainsAny(elems ...T) bool {
+	m.RLock()
+	defer m.RUnlock()
+	for _, elem := range elems {
+		if m.data[elem] {
+			return true
+		}
+	}
+	return false
+}
+
+// Union returns a new set with the elements in either m or o.
+func (m *Set[T]) Union(o *Set[T]) *Set[T] {
+	// The other set is copied before locking m, so that a.Union(b) and
+	// b.Union(a) never wait for each other.
+	data := o.cloneData()
+	m.RLock()
+	defer m.RUnlock()
+	for k := range m.data {
+		data[k] = true
+	}
+	return &Set[T]{data: data}
+}
+
+// Intersect returns a new set with the elements in both m and o.
+func (m *Set[T]) Intersect(o *Set[T]) *Set[T] {
+	other := o.cloneData()
+	m.RLock()
+	defer m.RUnlock()
+	data := map[T]bool{}
+	for k := range m.data {
+		if other[k] {
+			data[k] = true
+		}
+	}
+	return &Set[T]{data: data}
+}
+
+// Difference returns a new set with the elements in m but not in o.
+func (m *Set[T]) Difference(o *Set[T]) *Set[T] {
+	other := o.cloneData()
+	m.RLock()
+	defer m.RUnlock()
+	data := map[T]bool{}
+	for k := range m.data {
+		if !other[k] {
+			data[k] = true
+		}
+	}
+	return &Set[T]{data: data}
+}
+
+// SymmetricDifference returns a new set with the elements in either m or o,
+// but not both.
+func (m *Set[T]) SymmetricDifference(o *Set[T]) *Set[T] {
+	data := o.cloneData()
+	m.RLock()
+	defer m.RUnlock()
+	for k := range m.data {
+		if data[k] {
+			delete(data, k)
+		} else {
+			data[k] = true
+		}
+	}
+	return &Set[T]{data: data}
+}
+
+// IsSubset reports whether all the elements of m are in o.
+func (m *Set[T]) IsSubset(o *Set[T]) bool {
+	other := o.cloneData()
+	m.RLock()
+	defer m.RUnlock()
+	if len(m.data) > len(other) {
+		return false
+	}
+	for k := range m.data {
+		if !other[k] {
+			return false
+		}
+	}
+	return true
+}
+
+// IsSuperset reports whether all the elements of o are in m.
+func (m *Set[T]) IsSuperset(o *Set[T]) bool {
+	return o.IsSubset(m)
+}
+
+// Equal reports whether m and o have the same elements.
+func (m *Set[T]) Equal(o *Set[T]) bool {
+	other := o.cloneData()
+	m.RLock()
+	defer m.RUnlock()
+	if len(m.data) != len(other) {
+		return false
+	}
+	for k := range m.data {
+		if !other[k] {
+			return false
+		}
+	}
+	return true
+}
+
+// Jaccard returns the Jaccard similarity of m and o, i.e. the size of the
+// intersection divided by the size of the union. It's 1 if both are empty.
+func (m *Set[T]) Jaccard(o *Set[T]) float64 {
+	other := o.cloneData()
+	m.RLock()
+	defer m.RUnlock()
+	intersect := 0
+	for k := range m.data {
+		if other[k] {
+			intersect++
+		}
+	}
+	union := len(m.data) + len(other) - intersect
+	if union == 0 {
+		return 1
+	}
+	return float64(intersect) / float64(union)
+}
+
+// All returns an iterator over a snapshot of the elements, with no order
+// guaranteed.
+func (m *Set[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for _, elem := range m.GetElementsUnordered() {
+			if !yield(elem) {
+				return
+			}
+		}
+	}
+}
+
+func (m *Set[T]) GetElementsUnordered() []T {
 	m.RLock()
 	defer m.RUnlock()
 
-	ret := []string{}
+	ret := make([]T, 0, len(m.data))
 	for k, v := range m.data {
 		if v {
 			ret = append(ret, k)
@@ -119,13 +350,16 @@ func (m *Set) GetElementsUnordered() []string {
 	return ret
 }
 
-func (m *Set) GetElements() []string {
+// GetElements returns all the *copy* of elements in ascending order. Elements
+// of types other than strings and numbers are sorted by their formatted
+// strings.
+func (m *Set[T]) GetElements() []T {
 	elems := m.GetElementsUnordered()
-	sort.Strings(elems)
+	sortKeys(elems)
 	return elems
 }
 
-func (m *Set) Len() int {
+func (m *Set[T]) Len() int {
 	m.RLock()
 	defer m.RUnlock()
 	return len(m.data)
@@ -0,0 +1,86 @@
+package gomap
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestSetAlgebra(t *testing.T) {
+	a := NewSetFromSlice([]int{1, 2, 3, 4})
+	b := NewSetFromSlice([]int{3, 4, 5})
+	cases := []struct {
+		name     string
+		got      *Set[int]
+		expected []int
+	}{
+		{"Union", a.Union(b), []int{1, 2, 3, 4, 5}},
+		{"Intersect", a.Intersect(b), []int{3, 4}},
+		{"Difference", a.Difference(b), []int{1, 2}},
+		{"SymmetricDifference", a.SymmetricDifference(b), []int{1, 2, 5}},
+	}
+	for _, c := range cases {
+		if !reflect.DeepEqual(c.got.GetElements(), c.expected) {
+			t.Errorf("%s returns %v, expect %v", c.name, c.got.GetElements(), c.expected)
+		}
+	}
+
+	if a.IsSubset(b) || !a.Intersect(b).IsSubset(b) || !a.IsSuperset(a.Difference(b)) {
+		t.Error("IsSubset not correct")
+	}
+	if a.Equal(b) || !a.Equal(a.Clone()) || !a.Union(a).Equal(a) {
+		t.Error("Equal not correct")
+	}
+	if j := a.Jaccard(b); j != 0.4 {
+		t.Errorf("Jaccard returns %v, expect 0.4", j)
+	}
+	if j := NewSet().Jaccard(NewSet()); j != 1 {
+		t.Errorf("Jaccard of empty sets returns %v, expect 1", j)
+	}
+}
+
+func TestSetBatch(t *testing.T) {
+	s := NewSet()
+	s.AddAll("a", "b", "c")
+	if !s.ContainsAll("a", "c") || s.ContainsAll("a", "d") {
+		t.Error("ContainsAll not correct")
+	}
+	if !s.ContainsAny("d", "c") || s.ContainsAny("d", "e") {
+		t.Error("ContainsAny not correct")
+	}
+	s.RemoveAll("a", "b")
+	if !reflect.DeepEqual(s.GetElements(), []string{"c"}) {
+		t.Errorf("RemoveAll leaves %v", s.GetElements())
+	}
+	if elem, ok := s.Pop(); !ok || elem != "c" || s.Len() != 0 {
+		t.Error("Pop not correct")
+	}
+	if _, ok := s.Pop(); ok {
+		t.Error("Pop returns something from empty set")
+	}
+}
+
+func TestSetJSON(t *testing.T) {
+	s := NewSetFromSlice([]string{"b", "a"})
+	d, err := json.Marshal(s)
+	if err != nil || string(d) != `["a","b"]` {
+		t.Errorf("Marshal returns %s, %v", d, err)
+	}
+
+	decoded := NewSet()
+	if err := json.Unmarshal(d, decoded); err != nil || !decoded.Equal(s) {
+		t.Errorf("Unmarshal returns %v, %v", decoded.GetElements(), err)
+	}
+
+	legacy := NewSet()
+	if err := json.Unmarshal([]byte(` {"a": true, "b": true, "c": false}`), legacy); err != nil || !legacy.Equal(s) {
+		t.Errorf("Unmarshal legacy returns %v, %v", legacy.GetElements(), err)
+	}
+
+	var ints struct {
+		IDs *Set[int64]
+	}
+	if err := json.Unmarshal([]byte(`{"IDs": [3, 1]}`), &ints); err != nil || !ints.IDs.ContainsAll(1, 3) {
+		t.Errorf("Unmarshal into nil set returns %v", err)
+	}
+}
@@ -0,0 +1,288 @@
+package gomap
+
+import (
+	"encoding/json"
+	"hash/maphash"
+	"iter"
+	"runtime"
+)
+
+// Sharded is a thread-safe key-value structure with the same API as Of, but
+// splits the keys into shards by hash, each guarded by its own lock. It's
+// preferred under heavy concurrent access from many cores, where the single
+// lock of Of becomes the bottleneck.
+// Reads only hold the read lock of one shard, and the snapshots like
+// GetItems() copy one shard at a time, so they never block the whole map.
+// Reads are not lock-free, which would need copy-on-write shards or an
+// allocation per write. Use sync.Map instead for read-mostly keys, which are
+// written once and read many times.
+// Example usage:
+//   m := gomap.NewSharded[string, int](0)
+//   m.Update(word, func(n int, exists bool) int {
+//       return n + 1
+//   })
+type Sharded[K comparable, V any] struct {
+	shards []*Of[K, V]
+	mask   uint64
+	seed   maphash.Seed
+}
+
+// NewSharded creates a new sharded map. The number of shards is rounded up to
+// the power of 2, and defaults to 4 * GOMAXPROCS if it's not positive.
+func NewSharded[K comparable, V any](shards int) *Sharded[K, V] {
+	if shards <= 0 {
+		shards = runtime.GOMAXPROCS(0) * 4
+	}
+	n := 1
+	for n < shards {
+		n <<= 1
+	}
+	m := &Sharded[K, V]{
+		shards: make([]*Of[K, V], n),
+		mask:   uint64(n - 1),
+		seed:   maphash.MakeSeed(),
+	}
+	for i := range m.shards {
+		m.shards[i] = NewOf[K, V]()
+	}
+	return m
+}
+
+// WrapSharded copies the content of a built-in map into a new sharded map.
+// Unlike WrapOf(), the built-in map is not referred afterwards.
+func WrapSharded[K comparable, V any](m map[K]V, shards int) *Sharded[K, V] {
+	ret := NewSharded[K, V](shards)
+	for k, v := range m {
+		ret.shard(k).data[k] = v
+	}
+	return ret
+}
+
+func (m *Sharded[K, V]) shard(key K) *Of[K, V] {
+	return m.shards[m.hash(key)&m.mask]
+}
+
+// hash is consistent with ==, e.g. 0.0 and -0.0 are in the same shard, and
+// never allocates.
+func (m *Sharded[K, V]) hash(key K) uint64 {
+	return maphash.Comparable(m.seed, key)
+}
+
+// Shards returns the number of shards.
+func (m *Sharded[K, V]) Shards() int {
+	return len(m.shards)
+}
+
+// Unwrap merges all the shards into a built-in map, and return it. The same as
+// Of, the map structure won't function any more.
+func (m *Sharded[K, V]) Unwrap() map[K]V {
+	ret := map[K]V{}
+	for _, s := range m.shards {
+		for k, v := range s.Unwrap() {
+			ret[k] = v
+		}
+	}
+	return ret
+}
+
+// Clone shallow copies the keys and values to a new map structure with the
+// same number of shards.
+func (m *Sharded[K, V]) Clone() *Sharded[K, V] {
+	ret := &Sharded[K, V]{
+		shards: make([]*Of[K, V], len(m.shards)),
+		mask:   m.mask,
+		seed:   m.seed,
+	}
+	for i, s := range m.shards {
+		ret.shards[i] = s.Clone()
+	}
+	return ret
+}
+
+// MarshalJSON implements the json.Marshaller interface.
+func (m *Sharded[K, V]) MarshalJSON() ([]byte, error) {
+	data := map[K]V{}
+	for _, e := range m.GetItemsUnordered() {
+		data[e.Key] = e.Value
+	}
+	return json.Marshal(data)
+}
+
+// UnmarshalJSON implements the json.Unmarshaller interface.
+func (m *Sharded[K, V]) UnmarshalJSON(d []byte) error {
+	var data map[K]V
+	if err := json.Unmarshal(d, &data); err != nil {
+		return err
+	}
+	for k, v := range data {
+		m.Set(k, v)
+	}
+	return nil
+}
+
+// GobEncode implements the gob.GobEncoder interface, see Save().
+func (m *Sharded[K, V]) GobEncode() ([]byte, error) {
+	data := map[K]V{}
+	for _, e := range m.GetItemsUnordered() {
+		data[e.Key] = e.Value
+	}
+	return gobEncode(data)
+}
+
+// GobDecode implements the gob.GobDecoder interface, see Load(). It replaces
+// the content of the map.
+func (m *Sharded[K, V]) GobDecode(d []byte) error {
+	var data map[K]V
+	if err := gobDecode(d, &data); err != nil {
+		return err
+	}
+	buckets := make([]map[K]V, len(m.shards))
+	for i := range buckets {
+		buckets[i] = map[K]V{}
+	}
+	for k, v := range data {
+		buckets[m.hash(k)&m.mask][k] = v
+	}
+	for i, s := range m.shards {
+		s.Lock()
+		s.data = buckets[i]
+		s.Unlock()
+	}
+	return nil
+}
+
+func (m *Sharded[K, V]) Set(key K, value V) {
+	m.shard(key).Set(key, value)
+}
+
+func (m *Sharded[K, V]) Delete(key K) {
+	m.shard(key).Delete(key)
+}
+
+// Get returns the value by key, or the zero value if not exists.
+func (m *Sharded[K, V]) Get(key K) V {
+	return m.shard(key).Get(key)
+}
+
+// Load is the same as Get(), except it reports whether the key exists.
+func (m *Sharded[K, V]) Load(key K) (V, bool) {
+	return m.shard(key).Load(key)
+}
+
+func (m *Sharded[K, V]) Exists(key K) bool {
+	return m.shard(key).Exists(key)
+}
+
+// GetOrCreate is the same as Of.GetOrCreate().
+func (m *Sharded[K, V]) GetOrCreate(key K, createFn func() V) V {
+	return m.shard(key).GetOrCreate(key, createFn)
+}
+
+// GetOrCreateE is the same as Of.GetOrCreateE().
+func (m *Sharded[K, V]) GetOrCreateE(key K, createFn func() (V, error)) (V, error) {
+	return m.shard(key).GetOrCreateE(key, createFn)
+}
+
+// Update is the same as Of.Update().
+func (m *Sharded[K, V]) Update(key K, fn func(value V, exists bool) V) V {
+	return m.shard(key).Update(key, fn)
+}
+
+// CompareAndSwap is the same as Of.CompareAndSwap().
+func (m *Sharded[K, V]) CompareAndSwap(key K, old, new V) bool {
+	return m.shard(key).CompareAndSwap(key, old, new)
+}
+
+// LoadAndDelete deletes the key, and returns the previous value if any.
+func (m *Sharded[K, V]) LoadAndDelete(key K) (V, bool) {
+	return m.shard(key).LoadAndDelete(key)
+}
+
+// Range calls fn for each key/value pair until fn returns false. It iterates
+// over the snapshot of each shard in turn, so fn may modify the map.
+func (m *Sharded[K, V]) Range(fn func(key K, value V) bool) {
+	for _, s := range m.shards {
+		for _, e := range s.GetItemsUnordered() {
+			if !fn(e.Key, e.Value) {
+				return
+			}
+		}
+	}
+}
+
+// All returns an iterator over the key/value pairs, with no order guaranteed.
+func (m *Sharded[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		m.Range(yield)
+	}
+}
+
+// Keys returns an iterator over the keys, with no order guaranteed.
+func (m *Sharded[K, V]) Keys() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		m.Range(func(k K, _ V) bool {
+			return yield(k)
+		})
+	}
+}
+
+// Values returns an iterator over the values, with no order guaranteed.
+func (m *Sharded[K, V]) Values() iter.Seq[V] {
+	return func(yield func(V) bool) {
+		m.Range(func(_ K, v V) bool {
+			return yield(v)
+		})
+	}
+}
+
+// GetKeysUnordered returns all the *copy* of keys, but no order is guaranteed.
+func (m *Sharded[K, V]) GetKeysUnordered() []K {
+	ret := make([]K, 0, m.Len())
+	for _, s := range m.shards {
+		ret = s.appendKeys(ret)
+	}
+	return ret
+}
+
+// GetKeys returns all the *copy* of keys in the same order of Of.GetKeys().
+func (m *Sharded[K, V]) GetKeys() []K {
+	keys := m.GetKeysUnordered()
+	sortKeys(keys)
+	return keys
+}
+
+// GetValues returns all the *copy* of values, but no order is guaranteed.
+func (m *Sharded[K, V]) GetValues() []V {
+	ret := make([]V, 0, m.Len())
+	for _, s := range m.shards {
+		ret = s.appendValues(ret)
+	}
+	return ret
+}
+
+// GetItemsUnordered returns all the *copy* of key/value pairs, but no order is guaranteed.
+func (m *Sharded[K, V]) GetItemsUnordered() []Entry[K, V] {
+	ret := make([]Entry[K, V], 0, m.Len())
+	for _, s := range m.shards {
+		ret = s.appendItems(ret)
+	}
+	return ret
+}
+
+// GetItems returns all the *copy* of key/value pairs, and sort them by keys
+// in the same order of GetKeys().
+func (m *Sharded[K, V]) GetItems() []Entry[K, V] {
+	items := m.GetItemsUnordered()
+	sortEntries(items)
+	return items
+}
+
+// Len returns the size of the map. It's not an atomic snapshot across shards
+// under concurrent writes.
+func (m *Sharded[K, V]) Len() int {
+	n := 0
+	for _, s := range m.shards {
+		n += s.Len()
+	}
+	return n
+}
@@ -0,0 +1,206 @@
+package gomap
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestShardedShards(t *testing.T) {
+	if n := NewSharded[string, int](5).Shards(); n != 8 {
+		t.Errorf("Shards rounded to %d, expect 8", n)
+	}
+	if n := NewSharded[string, int](0).Shards(); n <= 0 || n&(n-1) != 0 {
+		t.Errorf("Default shards %d is not the power of 2", n)
+	}
+}
+
+func TestShardedGetAndSet(t *testing.T) {
+	m := WrapSharded(map[string]int{"key1": 1, "key2": 2}, 4)
+	m.Set("key3", 3)
+	if m.Len() != 3 || m.Get("key1") != 1 || m.Get("key3") != 3 {
+		t.Error("Unexpected content in the sharded map.")
+	}
+	if v, ok := m.LoadAndDelete("key2"); !ok || v != 2 || m.Exists("key2") {
+		t.Error("LoadAndDelete not taking effect")
+	}
+	if !m.CompareAndSwap("key1", 1, 10) || m.Get("key1") != 10 {
+		t.Error("CompareAndSwap not taking effect")
+	}
+
+	keys := m.GetKeys()
+	if len(keys) != 2 || keys[0] != "key1" || keys[1] != "key3" {
+		t.Errorf("GetKeys returns %v", keys)
+	}
+	items := m.GetItems()
+	if len(items) != 2 || items[0].Value != 10 || items[1].Value != 3 {
+		t.Errorf("GetItems returns %v", items)
+	}
+
+	clone := m.Clone()
+	clone.Set("key1", 100)
+	if m.Get("key1") != 10 || clone.Get("key3") != 3 {
+		t.Error("Clone refers to the old memory")
+	}
+
+	d, err := json.Marshal(m)
+	if err != nil || string(d) != `{"key1":10,"key3":3}` {
+		t.Errorf("Marshal returns %s, %v", d, err)
+	}
+	decoded := NewSharded[string, int](2)
+	if err := json.Unmarshal(d, decoded); err != nil || decoded.Len() != 2 || decoded.Get("key1") != 10 {
+		t.Errorf("Unmarshal returns %v, %v", decoded.Unwrap(), err)
+	}
+}
+
+func TestShardedKeyTypes(t *testing.T) {
+	type point struct{ x, y int }
+	m := NewSharded[point, bool](16)
+	m.Set(point{1, 2}, true)
+	if !m.Get(point{1, 2}) || m.Exists(point{2, 1}) {
+		t.Error("Struct keys are not hashed consistently")
+	}
+
+	floats := NewSharded[float64, int](16)
+	negZero := math.Copysign(0, -1)
+	floats.Set(0.0, 1)
+	floats.Set(negZero, 2)
+	if floats.Len() != 1 || floats.Get(0.0) != 2 {
+		t.Errorf("0.0 and -0.0 are different keys: %v", floats.GetItems())
+	}
+
+	type id string
+	named := NewSharded[id, int](16)
+	named.Set("a", 1)
+	if allocs := testing.AllocsPerRun(100, func() {
+		named.Get("a")
+	}); allocs != 0 {
+		t.Errorf("Get() of named string keys allocates %v times", allocs)
+	}
+
+	ints := NewSharded[int, int](16)
+	for i := 0; i < 1000; i++ {
+		ints.Set(i, i)
+	}
+	for i, s := range ints.shards {
+		if s.Len() == 0 {
+			t.Errorf("Shard %d is empty for sequential keys", i)
+		}
+	}
+}
+
+func TestShardedConcurrency(t *testing.T) {
+	m := NewSharded[string, int](0)
+	wg := sync.WaitGroup{}
+	wg.Add(100)
+	for i := 0; i < 100; i++ {
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				key := strconv.Itoa(j % 100)
+				m.Update(key, func(v int, _ bool) int {
+					return v + 1
+				})
+				m.Get(key)
+				if j%100 == 0 {
+					m.GetItemsUnordered()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	for _, e := range m.GetItemsUnordered() {
+		if e.Value != 1000 {
+			t.Errorf("Key %s updated %d times, expect 1000", e.Key, e.Value)
+		}
+	}
+}
+
+// The benchmarks simulate the read-mostly workload, with 1 write every 10
+// operations.
+const benchKeys = 1 << 16
+
+func benchKeyNames() []string {
+	keys := make([]string, benchKeys)
+	for i := range keys {
+		keys[i] = "key" + strconv.Itoa(i)
+	}
+	return keys
+}
+
+func BenchmarkMap(b *testing.B) {
+	keys := benchKeyNames()
+	m := NewOf[string, int]()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			key := keys[i&(benchKeys-1)]
+			if i%10 == 0 {
+				m.Set(key, i)
+			} else {
+				m.Get(key)
+			}
+			i++
+		}
+	})
+}
+
+func BenchmarkSharded(b *testing.B) {
+	keys := benchKeyNames()
+	m := NewSharded[string, int](0)
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			key := keys[i&(benchKeys-1)]
+			if i%10 == 0 {
+				m.Set(key, i)
+			} else {
+				m.Get(key)
+			}
+			i++
+		}
+	})
+}
+
+func BenchmarkSyncMap(b *testing.B) {
+	keys := benchKeyNames()
+	var m sync.Map
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			key := keys[i&(benchKeys-1)]
+			if i%10 == 0 {
+				m.Store(key, i)
+			} else {
+				m.Load(key)
+			}
+			i++
+		}
+	})
+}
+
+func BenchmarkMapGetItems(b *testing.B) {
+	keys := benchKeyNames()
+	m := NewOf[string, int]()
+	for i, key := range keys {
+		m.Set(key, i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.GetItemsUnordered()
+	}
+}
+
+func BenchmarkShardedGetItems(b *testing.B) {
+	keys := benchKeyNames()
+	m := NewSharded[string, int](0)
+	for i, key := range keys {
+		m.Set(key, i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.GetItemsUnordered()
+	}
+}
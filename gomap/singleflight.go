@@ -0,0 +1,78 @@
+package gomap
+
+import (
+	"fmt"
+	"sync"
+)
+
+// call is an in-flight creation of GetOrCreateE(), waited by the callers of
+// the same key.
+type call[V any] struct {
+	wg    sync.WaitGroup
+	value V
+	err   error
+	// panicValue is recovered from createFn, and re-panicked by all the
+	// callers.
+	panicValue interface{}
+}
+
+// createOnce implements the single-flight creation shared by the maps.
+// load and store are called with the lock held, where store returns the value
+// finally kept in the map, which is returned to all the callers. createFn is
+// called without the lock, and only one createFn of the same key is running
+// at a time. Failures are returned to the callers waiting for the same
+// creation, but never stored. If createFn panics, all the callers panic with
+// the same value.
+func createOnce[K comparable, V any](lock sync.Locker, calls *map[K]*call[V], key K,
+	load func() (V, bool), store func(V) V, createFn func() (V, error)) (value V, err error) {
+	lock.Lock()
+	if v, ok := load(); ok {
+		lock.Unlock()
+		return v, nil
+	}
+	if c, ok := (*calls)[key]; ok {
+		lock.Unlock()
+		c.wg.Wait()
+		if c.panicValue != nil {
+			panic(c.panicValue)
+		}
+		return c.value, c.err
+	}
+	if *calls == nil {
+		*calls = map[K]*call[V]{}
+	}
+	c := &call[V]{}
+	c.wg.Add(1)
+	(*calls)[key] = c
+	lock.Unlock()
+
+	normal := false
+	defer func() {
+		if !normal && c.panicValue == nil {
+			// createFn called runtime.Goexit(), e.g. t.Fatal(), let the
+			// waiters fail instead of blocking forever.
+			c.err = fmt.Errorf("gomap: creating %v exited", key)
+		}
+		lock.Lock()
+		if c.err == nil && c.panicValue == nil {
+			c.value = store(c.value)
+		}
+		delete(*calls, key)
+		lock.Unlock()
+		c.wg.Done()
+		if c.panicValue != nil {
+			panic(c.panicValue)
+		}
+		value, err = c.value, c.err
+	}()
+	func() {
+		defer func() {
+			if !normal {
+				c.panicValue = recover()
+			}
+		}()
+		c.value, c.err = createFn()
+		normal = true
+	}()
+	return
+}
@@ -0,0 +1,257 @@
+package gomap
+
+import (
+	"math/rand"
+	"time"
+)
+
+const (
+	skipMaxLevel = 32
+	// iterBatch is the number of items copied under the lock for each round of
+	// the iterators, so that the loop body runs without holding the lock.
+	iterBatch = 64
+)
+
+// skipNode is the node of skipList. span[i] is the number of nodes stepped
+// over by next[i] at level 0.
+type skipNode[T any] struct {
+	item T
+	next []*skipNode[T]
+	span []int
+}
+
+// skipList is an indexable skip list, which keeps the items ordered by cmp and
+// finds the rank of items in O(log n). It's not thread-safe.
+type skipList[T any] struct {
+	head   *skipNode[T]
+	level  int
+	length int
+	cmp    func(a, b T) int
+	rand   *rand.Rand
+}
+
+func newSkipList[T any](cmp func(a, b T) int) *skipList[T] {
+	return &skipList[T]{
+		head: &skipNode[T]{
+			next: make([]*skipNode[T], skipMaxLevel),
+			span: make([]int, skipMaxLevel),
+		},
+		level: 1,
+		cmp:   cmp,
+		rand:  rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+func (l *skipList[T]) randomLevel() int {
+	level := 1
+	for level < skipMaxLevel && l.rand.Int63()&3 == 0 {
+		level++
+	}
+	return level
+}
+
+// insert adds the item, or replaces the equal one. It returns whether it's
+// replaced.
+func (l *skipList[T]) insert(item T) bool {
+	var update [skipMaxLevel]*skipNode[T]
+	var rank [skipMaxLevel]int
+	x := l.head
+	for i := l.level - 1; i >= 0; i-- {
+		if i < l.level-1 {
+			rank[i] = rank[i+1]
+		}
+		for x.next[i] != nil && l.cmp(x.next[i].item, item) < 0 {
+			rank[i] += x.span[i]
+			x = x.next[i]
+		}
+		update[i] = x
+	}
+	if n := x.next[0]; n != nil && l.cmp(n.item, item) == 0 {
+		n.item = item
+		return true
+	}
+
+	level := l.randomLevel()
+	for i := l.level; i < level; i++ {
+		rank[i] = 0
+		update[i] = l.head
+		l.head.span[i] = l.length
+	}
+	if level > l.level {
+		l.level = level
+	}
+	n := &skipNode[T]{
+		item: item,
+		next: make([]*skipNode[T], level),
+		span: make([]int, level),
+	}
+	for i := 0; i < level; i++ {
+		n.next[i] = update[i].next[i]
+		update[i].next[i] = n
+		n.span[i] = update[i].span[i] - (rank[0] - rank[i])
+		update[i].span[i] = rank[0] - rank[i] + 1
+	}
+	for i := level; i < l.level; i++ {
+		update[i].span[i]++
+	}
+	l.length++
+	return false
+}
+
+// remove deletes the item equal to the given one, and returns the deleted.
+func (l *skipList[T]) remove(item T) (T, bool) {
+	var update [skipMaxLevel]*skipNode[T]
+	x := l.head
+	for i := l.level - 1; i >= 0; i-- {
+		for x.next[i] != nil && l.cmp(x.next[i].item, item) < 0 {
+			x = x.next[i]
+		}
+		update[i] = x
+	}
+	n := x.next[0]
+	if n == nil || l.cmp(n.item, item) != 0 {
+		var zero T
+		return zero, false
+	}
+	for i := 0; i < l.level; i++ {
+		if update[i].next[i] == n {
+			update[i].span[i] += n.span[i] - 1
+			update[i].next[i] = n.next[i]
+		} else {
+			update[i].span[i]--
+		}
+	}
+	for l.level > 1 && l.head.next[l.level-1] == nil {
+		l.level--
+	}
+	l.length--
+	return n.item, true
+}
+
+// seek returns the first node not less than the item if inclusive, or greater
+// than the item otherwise.
+func (l *skipList[T]) seek(item T, inclusive bool) *skipNode[T] {
+	x := l.head
+	for i := l.level - 1; i >= 0; i-- {
+		for x.next[i] != nil {
+			c := l.cmp(x.next[i].item, item)
+			if c > 0 || (c == 0 && inclusive) {
+				break
+			}
+			x = x.next[i]
+		}
+	}
+	return x.next[0]
+}
+
+// seekFunc is the same as seek(), except the items are compared with the
+// target by compare, which is consistent with the order of the list but may
+// compare only part of the item, e.g. the value of entries.
+func (l *skipList[T]) seekFunc(compare func(item T) int, inclusive bool) *skipNode[T] {
+	x := l.head
+	for i := l.level - 1; i >= 0; i-- {
+		for x.next[i] != nil {
+			c := compare(x.next[i].item)
+			if c > 0 || (c == 0 && inclusive) {
+				break
+			}
+			x = x.next[i]
+		}
+	}
+	return x.next[0]
+}
+
+// floor returns the last node not greater than the item.
+func (l *skipList[T]) floor(item T) *skipNode[T] {
+	return l.floorFunc(func(x T) int {
+		return l.cmp(x, item)
+	})
+}
+
+// floorFunc is the same as floor(), except the items are compared with the
+// target by compare, like seekFunc().
+func (l *skipList[T]) floorFunc(compare func(item T) int) *skipNode[T] {
+	x := l.head
+	for i := l.level - 1; i >= 0; i-- {
+		for x.next[i] != nil && compare(x.next[i].item) <= 0 {
+			x = x.next[i]
+		}
+	}
+	if x == l.head {
+		return nil
+	}
+	return x
+}
+
+// find returns the node equal to the item.
+func (l *skipList[T]) find(item T) *skipNode[T] {
+	n := l.seek(item, true)
+	if n == nil || l.cmp(n.item, item) != 0 {
+		return nil
+	}
+	return n
+}
+
+// rank returns the 0-based position of the item.
+func (l *skipList[T]) rank(item T) (int, bool) {
+	rank := 0
+	x := l.head
+	for i := l.level - 1; i >= 0; i-- {
+		for x.next[i] != nil && l.cmp(x.next[i].item, item) <= 0 {
+			rank += x.span[i]
+			x = x.next[i]
+		}
+		if x != l.head && l.cmp(x.item, item) == 0 {
+			return rank - 1, true
+		}
+	}
+	return 0, false
+}
+
+// at returns the node at the 0-based position.
+func (l *skipList[T]) at(rank int) *skipNode[T] {
+	if rank < 0 || rank >= l.length {
+		return nil
+	}
+	traversed := 0
+	x := l.head
+	for i := l.level - 1; i >= 0; i-- {
+		for x.next[i] != nil && traversed+x.span[i] <= rank+1 {
+			traversed += x.span[i]
+			x = x.next[i]
+		}
+		if traversed == rank+1 {
+			return x
+		}
+	}
+	return nil
+}
+
+// collect copies at most n items from the node onwards. A negative n means all.
+func (l *skipList[T]) collect(from *skipNode[T], n int) []T {
+	var ret []T
+	for x := from; x != nil && n != 0; x = x.next[0] {
+		ret = append(ret, x.item)
+		n--
+	}
+	return ret
+}
+
+// iterate yields the items in batches. copyBatch copies the first batch if last
+// is nil, or the batch after last otherwise. The lock is only held by
+// copyBatch, so yield may modify the list.
+func iterate[T any](copyBatch func(last *T) []T, yield func(T) bool) {
+	var last *T
+	for {
+		items := copyBatch(last)
+		for _, item := range items {
+			if !yield(item) {
+				return
+			}
+		}
+		if len(items) < iterBatch {
+			return
+		}
+		last = &items[len(items)-1]
+	}
+}
@@ -0,0 +1,227 @@
+package gomap
+
+import (
+	"cmp"
+	"iter"
+	"sync"
+)
+
+// SortedMap is a thread-safe key-value structure which keeps the keys sorted
+// incrementally, by a skip list. Besides the lookups by key in O(log n), it
+// supports the ordered queries like Floor(), Ceiling(), Rank() and Between(),
+// without sorting all the keys on each call like Map.GetKeys(). Use
+// ValueSortedMap to sort by values instead, e.g. leaderboards.
+// Example usage:
+//   scores := gomap.NewSortedMap[int, string]()
+//   scores.Set(score, user)
+//   for score, user := range scores.IterFrom(60) {
+//       ...
+//   }
+type SortedMap[K comparable, V any] struct {
+	sync.RWMutex
+	list *skipList[Entry[K, V]]
+}
+
+// NewSortedMap creates a new map structure sorted by keys in ascending order.
+func NewSortedMap[K cmp.Ordered, V any]() *SortedMap[K, V] {
+	return NewSortedMapFunc[K, V](cmp.Compare[K])
+}
+
+// NewSortedMapFunc creates a new map structure sorted by compare, which
+// returns a negative number if a < b, a positive number if a > b, and zero if
+// they are the same key.
+func NewSortedMapFunc[K comparable, V any](compare func(a, b K) int) *SortedMap[K, V] {
+	return &SortedMap[K, V]{
+		list: newSkipList(func(a, b Entry[K, V]) int {
+			return compare(a.Key, b.Key)
+		}),
+	}
+}
+
+// GobEncode implements the gob.GobEncoder interface, see Save().
+func (m *SortedMap[K, V]) GobEncode() ([]byte, error) {
+	return gobEncode(m.GetItems())
+}
+
+// GobDecode implements the gob.GobDecoder interface, see Load(). It replaces
+// the content of the map, which must be created by NewSortedMap() or
+// NewSortedMapFunc() for the order.
+func (m *SortedMap[K, V]) GobDecode(d []byte) error {
+	var items []Entry[K, V]
+	if err := gobDecode(d, &items); err != nil {
+		return err
+	}
+	m.Lock()
+	defer m.Unlock()
+	m.list = newSkipList(m.list.cmp)
+	for _, e := range items {
+		m.list.insert(e)
+	}
+	return nil
+}
+
+func (m *SortedMap[K, V]) Set(key K, value V) {
+	m.Lock()
+	m.list.insert(Entry[K, V]{key, value})
+	m.Unlock()
+}
+
+func (m *SortedMap[K, V]) Delete(key K) {
+	m.Lock()
+	m.list.remove(Entry[K, V]{Key: key})
+	m.Unlock()
+}
+
+// LoadAndDelete deletes the key, and returns the previous value if any.
+func (m *SortedMap[K, V]) LoadAndDelete(key K) (V, bool) {
+	m.Lock()
+	defer m.Unlock()
+	e, ok := m.list.remove(Entry[K, V]{Key: key})
+	return e.Value, ok
+}
+
+// Get returns the value by key, or the zero value if not exists.
+func (m *SortedMap[K, V]) Get(key K) V {
+	v, _ := m.Load(key)
+	return v
+}
+
+// Load is the same as Get(), except it reports whether the key exists.
+func (m *SortedMap[K, V]) Load(key K) (V, bool) {
+	m.RLock()
+	defer m.RUnlock()
+	if n := m.list.find(Entry[K, V]{Key: key}); n != nil {
+		return n.item.Value, true
+	}
+	var zero V
+	return zero, false
+}
+
+func (m *SortedMap[K, V]) Exists(key K) bool {
+	_, ok := m.Load(key)
+	return ok
+}
+
+// Len returns the size of the map.
+func (m *SortedMap[K, V]) Len() int {
+	m.RLock()
+	defer m.RUnlock()
+	return m.list.length
+}
+
+// GetKeys returns all the *copy* of keys in order.
+func (m *SortedMap[K, V]) GetKeys() []K {
+	items := m.GetItems()
+	ret := make([]K, len(items))
+	for i, e := range items {
+		ret[i] = e.Key
+	}
+	return ret
+}
+
+// GetValues returns all the *copy* of values in the order of keys.
+func (m *SortedMap[K, V]) GetValues() []V {
+	items := m.GetItems()
+	ret := make([]V, len(items))
+	for i, e := range items {
+		ret[i] = e.Value
+	}
+	return ret
+}
+
+// GetItems returns all the *copy* of key/value pairs in the order of keys.
+func (m *SortedMap[K, V]) GetItems() []Entry[K, V] {
+	m.RLock()
+	defer m.RUnlock()
+	return m.list.collect(m.list.head.next[0], -1)
+}
+
+// Min returns the entry with the smallest key.
+func (m *SortedMap[K, V]) Min() (Entry[K, V], bool) {
+	return m.At(0)
+}
+
+// Max returns the entry with the largest key.
+func (m *SortedMap[K, V]) Max() (Entry[K, V], bool) {
+	m.RLock()
+	defer m.RUnlock()
+	return nodeEntry(m.list.at(m.list.length - 1))
+}
+
+// Floor returns the entry with the largest key not greater than the given key.
+func (m *SortedMap[K, V]) Floor(key K) (Entry[K, V], bool) {
+	m.RLock()
+	defer m.RUnlock()
+	return nodeEntry(m.list.floor(Entry[K, V]{Key: key}))
+}
+
+// Ceiling returns the entry with the smallest key not less than the given key.
+func (m *SortedMap[K, V]) Ceiling(key K) (Entry[K, V], bool) {
+	m.RLock()
+	defer m.RUnlock()
+	return nodeEntry(m.list.seek(Entry[K, V]{Key: key}, true))
+}
+
+// Rank returns the 0-based position of the key in order.
+func (m *SortedMap[K, V]) Rank(key K) (int, bool) {
+	m.RLock()
+	defer m.RUnlock()
+	return m.list.rank(Entry[K, V]{Key: key})
+}
+
+// At returns the entry at the 0-based position in order.
+func (m *SortedMap[K, V]) At(rank int) (Entry[K, V], bool) {
+	m.RLock()
+	defer m.RUnlock()
+	return nodeEntry(m.list.at(rank))
+}
+
+// Between returns the *copy* of entries whose keys are within [from, to], in
+// order.
+func (m *SortedMap[K, V]) Between(from, to K) []Entry[K, V] {
+	m.RLock()
+	defer m.RUnlock()
+	var ret []Entry[K, V]
+	pivot := Entry[K, V]{Key: to}
+	for x := m.list.seek(Entry[K, V]{Key: from}, true); x != nil && m.list.cmp(x.item, pivot) <= 0; x = x.next[0] {
+		ret = append(ret, x.item)
+	}
+	return ret
+}
+
+// All returns an iterator over the key/value pairs in order. The map is not
+// locked while running the loop body, so it may modify the map.
+func (m *SortedMap[K, V]) All() iter.Seq2[K, V] {
+	return m.iter(nil)
+}
+
+// IterFrom returns an iterator over the key/value pairs in order, starting
+// from the smallest key not less than the given key.
+func (m *SortedMap[K, V]) IterFrom(key K) iter.Seq2[K, V] {
+	return m.iter(&Entry[K, V]{Key: key})
+}
+
+func (m *SortedMap[K, V]) iter(from *Entry[K, V]) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		iterate(func(last *Entry[K, V]) []Entry[K, V] {
+			m.RLock()
+			defer m.RUnlock()
+			start := m.list.head.next[0]
+			if last != nil {
+				start = m.list.seek(*last, false)
+			} else if from != nil {
+				start = m.list.seek(*from, true)
+			}
+			return m.list.collect(start, iterBatch)
+		}, func(e Entry[K, V]) bool {
+			return yield(e.Key, e.Value)
+		})
+	}
+}
+
+func nodeEntry[K comparable, V any](n *skipNode[Entry[K, V]]) (Entry[K, V], bool) {
+	if n == nil {
+		return Entry[K, V]{}, false
+	}
+	return n.item, true
+}
@@ -0,0 +1,108 @@
+package gomap
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestSortedMapRandom(t *testing.T) {
+	m := NewSortedMap[int, int]()
+	expected := map[int]int{}
+	for i := 0; i < 5000; i++ {
+		key := rand.Intn(1000)
+		if rand.Intn(3) == 0 {
+			m.Delete(key)
+			delete(expected, key)
+		} else {
+			m.Set(key, i)
+			expected[key] = i
+		}
+	}
+
+	var keys []int
+	for k := range expected {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	if m.Len() != len(keys) || !reflect.DeepEqual(m.GetKeys(), keys) {
+		t.Fatal("Keys not sorted or lost")
+	}
+	for i, k := range keys {
+		if rank, ok := m.Rank(k); !ok || rank != i {
+			t.Fatalf("Rank of %d is %d, expect %d", k, rank, i)
+		}
+		if e, ok := m.At(i); !ok || e.Key != k || e.Value != expected[k] {
+			t.Fatalf("At %d returns %v, expect key %d", i, e, k)
+		}
+	}
+}
+
+func TestSortedMapQueries(t *testing.T) {
+	m := NewSortedMap[int, string]()
+	for _, k := range []int{50, 10, 30, 40, 20} {
+		m.Set(k, "")
+	}
+	if e, ok := m.Floor(35); !ok || e.Key != 30 {
+		t.Errorf("Floor(35) returns %v", e)
+	}
+	if e, ok := m.Floor(30); !ok || e.Key != 30 {
+		t.Errorf("Floor(30) returns %v", e)
+	}
+	if _, ok := m.Floor(5); ok {
+		t.Error("Floor(5) returns something")
+	}
+	if e, ok := m.Ceiling(35); !ok || e.Key != 40 {
+		t.Errorf("Ceiling(35) returns %v", e)
+	}
+	if _, ok := m.Ceiling(55); ok {
+		t.Error("Ceiling(55) returns something")
+	}
+	if e, _ := m.Min(); e.Key != 10 {
+		t.Errorf("Min returns %v", e)
+	}
+	if e, _ := m.Max(); e.Key != 50 {
+		t.Errorf("Max returns %v", e)
+	}
+	if _, ok := m.Rank(35); ok {
+		t.Error("Rank of non-exists key")
+	}
+
+	var between []int
+	for _, e := range m.Between(15, 40) {
+		between = append(between, e.Key)
+	}
+	if !reflect.DeepEqual(between, []int{20, 30, 40}) {
+		t.Errorf("Between returns %v", between)
+	}
+}
+
+func TestSortedMapIter(t *testing.T) {
+	m := NewSortedMapFunc[int, int](func(a, b int) int {
+		// Descending order.
+		return b - a
+	})
+	for i := 0; i < 200; i++ {
+		m.Set(i, i)
+	}
+	var keys []int
+	for k := range m.IterFrom(150) {
+		keys = append(keys, k)
+		// Modifying in iterating should not deadlock.
+		m.Delete(k)
+	}
+	if len(keys) != 151 || keys[0] != 150 || keys[150] != 0 {
+		t.Errorf("IterFrom returns %d keys from %d", len(keys), keys[0])
+	}
+	count := 0
+	for range m.All() {
+		count++
+		if count == 10 {
+			break
+		}
+	}
+	if m.Len() != 49 || count != 10 {
+		t.Errorf("Unexpected len %d after iterating", m.Len())
+	}
+}
@@ -0,0 +1,272 @@
+package gomap
+
+import (
+	"cmp"
+	"iter"
+	"sync"
+)
+
+// ValueSortedMap is a thread-safe key-value structure which looks up by key,
+// but keeps the entries sorted by value incrementally, e.g. leaderboards. The
+// entries of the same value are sorted by key. Besides the lookups by key in
+// O(1), it supports the ordered queries like Floor(), Ceiling(), Rank(), At()
+// and Between() in O(log n).
+// Example usage:
+//   // Higher scores go first.
+//   board := gomap.NewValueSortedMapFunc[string, int](func(a, b int) int {
+//       return b - a
+//   }, strings.Compare)
+//   board.Update(user, func(score int, exists bool) int {
+//       return score + delta
+//   })
+//   rank, _ := board.Rank(user)
+//   top10 := board.Slice(0, 10)
+type ValueSortedMap[K comparable, V any] struct {
+	sync.RWMutex
+	data         map[K]V
+	list         *skipList[Entry[K, V]]
+	compareValue func(a, b V) int
+}
+
+// NewValueSortedMap creates a new map structure sorted by values in ascending
+// order.
+func NewValueSortedMap[K cmp.Ordered, V cmp.Ordered]() *ValueSortedMap[K, V] {
+	return NewValueSortedMapFunc[K, V](cmp.Compare[V], cmp.Compare[K])
+}
+
+// NewValueSortedMapFunc creates a new map structure sorted by compareValue,
+// and then by compareKey for the same value. Both return a negative number if
+// a < b, a positive number if a > b, and zero otherwise.
+func NewValueSortedMapFunc[K comparable, V any](compareValue func(a, b V) int, compareKey func(a, b K) int) *ValueSortedMap[K, V] {
+	return &ValueSortedMap[K, V]{
+		data: map[K]V{},
+		list: newSkipList(func(a, b Entry[K, V]) int {
+			if c := compareValue(a.Value, b.Value); c != 0 {
+				return c
+			}
+			return compareKey(a.Key, b.Key)
+		}),
+		compareValue: compareValue,
+	}
+}
+
+// GobEncode implements the gob.GobEncoder interface, see Save().
+func (m *ValueSortedMap[K, V]) GobEncode() ([]byte, error) {
+	return gobEncode(m.GetItems())
+}
+
+// GobDecode implements the gob.GobDecoder interface, see Load(). It replaces
+// the content of the map, which must be created by NewValueSortedMap() or
+// NewValueSortedMapFunc() for the order.
+func (m *ValueSortedMap[K, V]) GobDecode(d []byte) error {
+	var items []Entry[K, V]
+	if err := gobDecode(d, &items); err != nil {
+		return err
+	}
+	m.Lock()
+	defer m.Unlock()
+	m.data = make(map[K]V, len(items))
+	m.list = newSkipList(m.list.cmp)
+	for _, e := range items {
+		m.set(e.Key, e.Value)
+	}
+	return nil
+}
+
+func (m *ValueSortedMap[K, V]) Set(key K, value V) {
+	m.Lock()
+	m.set(key, value)
+	m.Unlock()
+}
+
+func (m *ValueSortedMap[K, V]) set(key K, value V) {
+	if old, ok := m.data[key]; ok {
+		m.list.remove(Entry[K, V]{key, old})
+	}
+	m.data[key] = value
+	m.list.insert(Entry[K, V]{key, value})
+}
+
+// Update sets the value of key by fn, with the current value if exists. It's
+// useful to accumulate scores.
+// IMPORTANT NOTE: The fn should not invoke any method in this map, otherwise
+// it will DEADLOCK.
+func (m *ValueSortedMap[K, V]) Update(key K, fn func(value V, exists bool) V) V {
+	m.Lock()
+	defer m.Unlock()
+	v, ok := m.data[key]
+	v = fn(v, ok)
+	m.set(key, v)
+	return v
+}
+
+func (m *ValueSortedMap[K, V]) Delete(key K) {
+	m.LoadAndDelete(key)
+}
+
+// LoadAndDelete deletes the key, and returns the previous value if any.
+func (m *ValueSortedMap[K, V]) LoadAndDelete(key K) (V, bool) {
+	m.Lock()
+	defer m.Unlock()
+	v, ok := m.data[key]
+	if ok {
+		delete(m.data, key)
+		m.list.remove(Entry[K, V]{key, v})
+	}
+	return v, ok
+}
+
+// Get returns the value by key, or the zero value if not exists.
+func (m *ValueSortedMap[K, V]) Get(key K) V {
+	v, _ := m.Load(key)
+	return v
+}
+
+// Load is the same as Get(), except it reports whether the key exists.
+func (m *ValueSortedMap[K, V]) Load(key K) (V, bool) {
+	m.RLock()
+	defer m.RUnlock()
+	v, ok := m.data[key]
+	return v, ok
+}
+
+func (m *ValueSortedMap[K, V]) Exists(key K) bool {
+	_, ok := m.Load(key)
+	return ok
+}
+
+// Len returns the size of the map.
+func (m *ValueSortedMap[K, V]) Len() int {
+	m.RLock()
+	defer m.RUnlock()
+	return len(m.data)
+}
+
+// GetKeys returns all the *copy* of keys in the order of values.
+func (m *ValueSortedMap[K, V]) GetKeys() []K {
+	items := m.GetItems()
+	ret := make([]K, len(items))
+	for i, e := range items {
+		ret[i] = e.Key
+	}
+	return ret
+}
+
+// GetItems returns all the *copy* of key/value pairs in the order of values.
+func (m *ValueSortedMap[K, V]) GetItems() []Entry[K, V] {
+	m.RLock()
+	defer m.RUnlock()
+	return m.list.collect(m.list.head.next[0], -1)
+}
+
+// Min returns the entry with the smallest value.
+func (m *ValueSortedMap[K, V]) Min() (Entry[K, V], bool) {
+	return m.At(0)
+}
+
+// Max returns the entry with the largest value.
+func (m *ValueSortedMap[K, V]) Max() (Entry[K, V], bool) {
+	m.RLock()
+	defer m.RUnlock()
+	return nodeEntry(m.list.at(m.list.length - 1))
+}
+
+// Floor returns the last entry whose value is not greater than the given one,
+// i.e. the one with the largest key among the same values.
+func (m *ValueSortedMap[K, V]) Floor(value V) (Entry[K, V], bool) {
+	m.RLock()
+	defer m.RUnlock()
+	return nodeEntry(m.list.floorFunc(func(e Entry[K, V]) int {
+		return m.compareValue(e.Value, value)
+	}))
+}
+
+// Ceiling returns the first entry whose value is not less than the given one,
+// i.e. the one with the smallest key among the same values.
+func (m *ValueSortedMap[K, V]) Ceiling(value V) (Entry[K, V], bool) {
+	m.RLock()
+	defer m.RUnlock()
+	return nodeEntry(m.seekValue(value))
+}
+
+// Rank returns the 0-based position of the key in the order of values.
+func (m *ValueSortedMap[K, V]) Rank(key K) (int, bool) {
+	m.RLock()
+	defer m.RUnlock()
+	v, ok := m.data[key]
+	if !ok {
+		return 0, false
+	}
+	return m.list.rank(Entry[K, V]{key, v})
+}
+
+// At returns the entry at the 0-based position in the order of values.
+func (m *ValueSortedMap[K, V]) At(rank int) (Entry[K, V], bool) {
+	m.RLock()
+	defer m.RUnlock()
+	return nodeEntry(m.list.at(rank))
+}
+
+// Slice returns the *copy* of entries at the positions within [from, to), in
+// the order of values, e.g. Slice(0, 10) for the top 10.
+func (m *ValueSortedMap[K, V]) Slice(from, to int) []Entry[K, V] {
+	m.RLock()
+	defer m.RUnlock()
+	if from < 0 {
+		from = 0
+	}
+	if to <= from {
+		return nil
+	}
+	return m.list.collect(m.list.at(from), to-from)
+}
+
+// Between returns the *copy* of entries whose values are within [from, to],
+// in order.
+func (m *ValueSortedMap[K, V]) Between(from, to V) []Entry[K, V] {
+	m.RLock()
+	defer m.RUnlock()
+	var ret []Entry[K, V]
+	for x := m.seekValue(from); x != nil && m.compareValue(x.item.Value, to) <= 0; x = x.next[0] {
+		ret = append(ret, x.item)
+	}
+	return ret
+}
+
+// seekValue returns the first node whose value is not less than the given one.
+func (m *ValueSortedMap[K, V]) seekValue(value V) *skipNode[Entry[K, V]] {
+	return m.list.seekFunc(func(e Entry[K, V]) int {
+		return m.compareValue(e.Value, value)
+	}, true)
+}
+
+// All returns an iterator over the key/value pairs in the order of values.
+// The map is not locked while running the loop body, so it may modify the
+// map.
+func (m *ValueSortedMap[K, V]) All() iter.Seq2[K, V] {
+	return m.iter(nil)
+}
+
+// IterFrom returns an iterator over the key/value pairs in the order of
+// values, starting from the smallest value not less than the given value.
+func (m *ValueSortedMap[K, V]) IterFrom(value V) iter.Seq2[K, V] {
+	return m.iter(&value)
+}
+
+func (m *ValueSortedMap[K, V]) iter(from *V) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		iterate(func(last *Entry[K, V]) []Entry[K, V] {
+			m.RLock()
+			defer m.RUnlock()
+			start := m.list.head.next[0]
+			if last != nil {
+				start = m.list.seek(*last, false)
+			} else if from != nil {
+				start = m.seekValue(*from)
+			}
+			return m.list.collect(start, iterBatch)
+		}, func(e Entry[K, V]) bool {
+			return yield(e.Key, e.Value)
+		})
+	}
+}
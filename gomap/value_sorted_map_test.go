@@ -0,0 +1,129 @@
+package gomap
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestValueSortedMapRandom(t *testing.T) {
+	m := NewValueSortedMap[int, int]()
+	expected := map[int]int{}
+	for i := 0; i < 5000; i++ {
+		key := rand.Intn(1000)
+		if rand.Intn(3) == 0 {
+			m.Delete(key)
+			delete(expected, key)
+		} else {
+			value := rand.Intn(100)
+			m.Set(key, value)
+			expected[key] = value
+		}
+	}
+
+	var items []Entry[int, int]
+	for k, v := range expected {
+		items = append(items, Entry[int, int]{k, v})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Value != items[j].Value {
+			return items[i].Value < items[j].Value
+		}
+		return items[i].Key < items[j].Key
+	})
+	if m.Len() != len(items) || !reflect.DeepEqual(m.GetItems(), items) {
+		t.Fatal("Entries not sorted by value or lost")
+	}
+	for i, e := range items {
+		if rank, ok := m.Rank(e.Key); !ok || rank != i {
+			t.Fatalf("Rank of %d is %d, expect %d", e.Key, rank, i)
+		}
+		if m.Get(e.Key) != e.Value {
+			t.Fatalf("Get(%d) = %d, expect %d", e.Key, m.Get(e.Key), e.Value)
+		}
+	}
+}
+
+func TestValueSortedMapLeaderboard(t *testing.T) {
+	board := NewValueSortedMapFunc[string, int](func(a, b int) int {
+		return b - a
+	}, strings.Compare)
+	for _, score := range []struct {
+		user  string
+		delta int
+	}{{"alice", 10}, {"bob", 30}, {"carol", 20}, {"alice", 25}, {"dave", 20}} {
+		board.Update(score.user, func(v int, exists bool) int {
+			return v + score.delta
+		})
+	}
+
+	if !reflect.DeepEqual(board.GetKeys(), []string{"alice", "bob", "carol", "dave"}) {
+		t.Errorf("Board = %v", board.GetItems())
+	}
+	if rank, ok := board.Rank("carol"); !ok || rank != 2 {
+		t.Errorf("Rank of carol is %d", rank)
+	}
+	if top := board.Slice(0, 2); len(top) != 2 || top[0].Key != "alice" || top[1].Key != "bob" {
+		t.Errorf("Top 2 = %v", top)
+	}
+	if len(board.Slice(3, 10)) != 1 || board.Slice(5, 10) != nil {
+		t.Error("Slice is not capped by size")
+	}
+	if e, _ := board.Max(); e.Key != "dave" {
+		t.Errorf("Max = %v", e)
+	}
+
+	// Scores within [30, 20] in the order of the board.
+	var between []string
+	for _, e := range board.Between(30, 20) {
+		between = append(between, e.Key)
+	}
+	if !reflect.DeepEqual(between, []string{"bob", "carol", "dave"}) {
+		t.Errorf("Between = %v", between)
+	}
+	var from []string
+	for k := range board.IterFrom(20) {
+		from = append(from, k)
+	}
+	if !reflect.DeepEqual(from, []string{"carol", "dave"}) {
+		t.Errorf("IterFrom = %v", from)
+	}
+
+	board.Delete("bob")
+	if rank, _ := board.Rank("carol"); rank != 1 || board.Exists("bob") {
+		t.Errorf("Rank of carol is %d after deleting bob", rank)
+	}
+}
+
+func TestValueSortedMapFloorCeiling(t *testing.T) {
+	m := NewValueSortedMap[string, int]()
+	m.Set("a", 10)
+	m.Set("b", 20)
+	m.Set("c", 20)
+	m.Set("d", 30)
+
+	cases := []struct {
+		value          int
+		floor, ceiling string
+	}{
+		{5, "", "a"},
+		{10, "a", "a"},
+		{15, "a", "b"},
+		{20, "c", "b"},
+		{25, "c", "d"},
+		{30, "d", "d"},
+		{35, "d", ""},
+	}
+	for _, c := range cases {
+		e, ok := m.Floor(c.value)
+		if ok != (c.floor != "") || e.Key != c.floor {
+			t.Errorf("Floor(%d) = %v, %v, expect %q", c.value, e, ok, c.floor)
+		}
+		e, ok = m.Ceiling(c.value)
+		if ok != (c.ceiling != "") || e.Key != c.ceiling {
+			t.Errorf("Ceiling(%d) = %v, %v, expect %q", c.value, e, ok, c.ceiling)
+		}
+	}
+}
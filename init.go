@@ -1,11 +1,15 @@
 package goutils
 
 import (
+	"sync"
+
+	"github.com/hoveychen/go-utils/flags"
 	"github.com/vharitonsky/iniflags"
 )
 
 var (
 	pkgInitFn = []func(){}
+	initOnce  sync.Once
 )
 
 // PkgInit is a deferred helper to initialize the package enviornment varible AFTER
@@ -28,9 +32,27 @@ func PkgInit(f func()) {
 
 // Init need to be execute in the beginning of main() to get PkgInit() to work.
 // NOTE: It already called flag.Parse() alternative method. No need to call flag.Parse() any more.
+// Flags are also read from environment variables, see flags.Parse() for the
+// precedence. Only the first call takes effect.
 func Init() {
-	iniflags.Parse()
-	for _, fn := range pkgInitFn {
-		fn()
-	}
+	runInit(func() error {
+		return flags.Parse(iniflags.Parse)
+	})
+}
+
+// runInit parses the flags and runs the PkgInit() functions once. It returns
+// false if already initialized.
+func runInit(parse func() error) bool {
+	ran := false
+	initOnce.Do(func() {
+		flags.SetConfigFetcher(FetchData)
+		if err := parse(); err != nil {
+			LogFatal(err)
+		}
+		for _, fn := range pkgInitFn {
+			fn()
+		}
+		ran = true
+	})
+	return ran
 }
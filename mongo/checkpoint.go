@@ -0,0 +1,102 @@
+package mongo
+
+import (
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+	goutils "github.com/hoveychen/go-utils"
+	"github.com/pkg/errors"
+)
+
+// CheckpointStore is a goutils.CheckpointStore saving checkpoints in a mongo
+// collection, one document per key.
+// Example usage:
+//   store := mongo.NewCheckpointStore("crawler", "checkpoints")
+//   m := goutils.NewMonitor(time.Now(), time.Minute, goutils.WithMonitorCheckpoint(store, "articles"))
+type CheckpointStore struct {
+	database   string
+	collection string
+	client     *DbClient
+}
+
+type checkpointDoc struct {
+	Key                string `bson:"_id"`
+	goutils.Checkpoint `bson:",inline"`
+}
+
+// NewCheckpointStore returns a store backed by the collection. The client is
+// determined by the router unless given by WithCheckpointClient().
+func NewCheckpointStore(db, col string, opts ...CheckpointStoreOption) *CheckpointStore {
+	s := &CheckpointStore{
+		database:   db,
+		collection: col,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+type CheckpointStoreOption func(*CheckpointStore)
+
+func WithCheckpointClient(client *DbClient) CheckpointStoreOption {
+	return func(s *CheckpointStore) {
+		s.client = client
+	}
+}
+
+func (s *CheckpointStore) open() (*mgo.Collection, *DbSession) {
+	if s.client == nil {
+		return Open(s.database, s.collection)
+	}
+	return s.client.Open(s.database, s.collection)
+}
+
+func (s *CheckpointStore) Load(key string) (*goutils.Checkpoint, error) {
+	c, session := s.open()
+	defer session.Close()
+
+	doc := &checkpointDoc{}
+	err := c.FindId(key).One(doc)
+	if err == mgo.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "Find checkpoint %s", key)
+	}
+	return &doc.Checkpoint, nil
+}
+
+func (s *CheckpointStore) Save(key string, cp *goutils.Checkpoint) error {
+	c, session := s.open()
+	defer session.Close()
+
+	if _, err := c.UpsertId(key, &checkpointDoc{Key: key, Checkpoint: *cp}); err != nil {
+		return errors.Wrapf(err, "Upsert checkpoint %s", key)
+	}
+	return nil
+}
+
+// CursorQuery returns the query of the records later than the cursor, ordered
+// by timeField and then idField. IDs in ObjectId hex are compared as ObjectId.
+// Example usage:
+//   cursor := m.NextCursor()
+//   iter := c.Find(mongo.CursorQuery("updatedAt", "_id", cursor)).Sort("updatedAt", "_id").Iter()
+//   for iter.Next(&doc) {
+//       ... Processing
+//       m.UpdateWithID(doc.UpdatedAt, doc.Id.Hex())
+//   }
+func CursorQuery(timeField, idField string, cursor goutils.Checkpoint) bson.M {
+	if cursor.ID == "" {
+		return bson.M{timeField: bson.M{"$gt": cursor.Time}}
+	}
+	var id interface{} = cursor.ID
+	if bson.IsObjectIdHex(cursor.ID) {
+		id = bson.ObjectIdHex(cursor.ID)
+	}
+	return bson.M{
+		"$or": []bson.M{
+			{timeField: bson.M{"$gt": cursor.Time}},
+			{timeField: cursor.Time, idField: bson.M{"$gt": id}},
+		},
+	}
+}
@@ -9,37 +9,72 @@ import (
 	"github.com/hoveychen/go-utils"
 	"github.com/hoveychen/go-utils/flags"
 	"github.com/hoveychen/go-utils/gomap"
+	"github.com/pkg/errors"
 )
 
 var (
-	clientCache     = gomap.New()
+	clientCache     = gomap.NewOf[string, *DbClient]()
 	numDbConcurrent = flags.Int("numDbConcurrent", 10, "Concurrent socket to db")
+
+	// dialedClients are the clients following the changes of
+	// --numDbConcurrent. It's registered under dialedLock along with reading
+	// the flag, so that a client dialed during the reload is never missed.
+	dialedClients []*DbClient
+	dialedLock    sync.Mutex
 )
 
+func init() {
+	flags.Reloadable("numDbConcurrent")
+	flags.OnChange("numDbConcurrent", func() {
+		dialedLock.Lock()
+		defer dialedLock.Unlock()
+		for _, c := range dialedClients {
+			c.setConcurrency(*numDbConcurrent)
+		}
+	})
+}
+
 type DbClient struct {
-	session      *mgo.Session
-	addr         string
-	dbConcurrent chan struct{}
-	dbWaitGroup  sync.WaitGroup
+	session        *mgo.Session
+	addr           string
+	dbConcurrent   chan struct{}
+	concurrentLock sync.RWMutex
+	dbWaitGroup    sync.WaitGroup
 }
 
 type DbSession struct {
-	client *DbClient
+	client     *DbClient
+	concurrent chan struct{}
 	*mgo.Session
 }
 
+// Dial returns the client connected to addr, which is shared by the same addr.
+// It fatals if failed to connect, see DialE() otherwise.
 func Dial(addr string) *DbClient {
-	cacheClient := clientCache.GetOrCreate(addr, func() interface{} {
+	c, err := DialE(addr)
+	if err != nil {
+		goutils.LogFatal(addr, err)
+	}
+	return c
+}
+
+// DialE is the same as Dial(), except it returns the error if failed to
+// connect. Failed connections are not cached, so the next call will retry.
+func DialE(addr string) (*DbClient, error) {
+	return clientCache.GetOrCreateE(addr, func() (*DbClient, error) {
 		s, err := mgo.Dial(addr)
 		if err != nil {
-			goutils.LogFatal(addr, err)
+			return nil, errors.Wrap(err, "Dial mongo")
 		}
 		s.SetMode(mgo.Eventual, true)
 
 		c := &DbClient{}
 		c.addr = addr
 		c.session = s
-		c.dbConcurrent = make(chan struct{}, *numDbConcurrent)
+		dialedLock.Lock()
+		c.dbConcurrent = make(chan struct{}, flags.Get(numDbConcurrent))
+		dialedClients = append(dialedClients, c)
+		dialedLock.Unlock()
 
 		go func() {
 			for range time.Tick(time.Minute * 5) {
@@ -50,14 +85,25 @@ func Dial(addr string) *DbClient {
 				}
 			}
 		}()
-		return c
+		return c, nil
 	})
-	return cacheClient.(*DbClient)
+}
+
+// setConcurrency changes the max number of opened sessions. Sessions opened
+// before are not counted in the new limit.
+func (c *DbClient) setConcurrency(n int) {
+	c.concurrentLock.Lock()
+	defer c.concurrentLock.Unlock()
+	c.dbConcurrent = make(chan struct{}, n)
 }
 
 func (c *DbClient) Open(db, collection string) (*mgo.Collection, *DbSession) {
+	c.concurrentLock.RLock()
+	concurrent := c.dbConcurrent
+	c.concurrentLock.RUnlock()
+
 	select {
-	case c.dbConcurrent <- struct{}{}:
+	case concurrent <- struct{}{}:
 	default:
 		goutils.LogFatal("Mongodb opened too many connections.", db, collection)
 
@@ -68,6 +114,7 @@ func (c *DbClient) Open(db, collection string) (*mgo.Collection, *DbSession) {
 	ownSession := &DbSession{}
 	ownSession.Session = mgoSession
 	ownSession.client = c
+	ownSession.concurrent = concurrent
 
 	return ownSession.DB(db).C(collection), ownSession
 }
@@ -86,6 +133,6 @@ func (c *DbClient) Wait() {
 
 func (d *DbSession) Close() {
 	d.Session.Close()
-	<-d.client.dbConcurrent
+	<-d.concurrent
 	d.client.dbWaitGroup.Done()
 }
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/globalsign/mgo/bson"
+	goutils "github.com/hoveychen/go-utils"
 	"github.com/pkg/errors"
 )
 
@@ -18,7 +19,8 @@ type LocalRepos struct {
 	collection      string
 	refreshInterval time.Duration
 	data            map[string]Hashable
-	ticker          *time.Ticker
+	ticker          goutils.Ticker
+	clock           goutils.Clock
 	query           bson.M
 	project         bson.M
 	client          *DbClient
@@ -59,6 +61,13 @@ func WithClient(client *DbClient) ReposOption {
 	}
 }
 
+// WithClock sets the clock to schedule refreshing.
+func WithClock(clock goutils.Clock) ReposOption {
+	return func(repos *LocalRepos) {
+		repos.clock = clock
+	}
+}
+
 type Hashable interface {
 	GetId() string
 }
@@ -68,6 +77,7 @@ func NewLocalRepos(db, col string, entryTmpl Hashable, opts ...ReposOption) *Loc
 		database:        db,
 		collection:      col,
 		refreshInterval: defaultRefreshInterval,
+		clock:           goutils.GetClock(),
 	}
 	for _, opt := range opts {
 		opt(repos)
@@ -87,9 +97,9 @@ func (r *LocalRepos) Init() {
 	}
 	r.reloadEntries()
 
-	r.ticker = time.NewTicker(r.refreshInterval)
+	r.ticker = r.clock.NewTicker(r.refreshInterval)
 	go func() {
-		for range r.ticker.C {
+		for range r.ticker.C() {
 			r.reloadEntries()
 		}
 	}()
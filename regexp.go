@@ -2,34 +2,49 @@ package goutils
 
 import (
 	"regexp"
+	"regexp/syntax"
+	"strings"
 
+	"github.com/hoveychen/go-utils/flags"
 	"github.com/hoveychen/go-utils/gomap"
+	"github.com/pkg/errors"
 )
 
 var (
-	cachedRegexp = gomap.New()
+	regexpCacheSize = flags.Int("regexpCacheSize", 10000, "Max number of compiled regexp patterns to cache. Non-positive for no bound.")
+
+	cachedRegexp = gomap.NewLRU(*regexpCacheSize)
 )
 
+func init() {
+	PkgInit(func() {
+		cachedRegexp.SetCapacity(*regexpCacheSize)
+	})
+}
+
 type Regexp struct {
 	*regexp.Regexp
-	err error
 }
 
-// CompileRegexp is the same as regexp.Compile(), except it cached all the
-// compiled patterns for performance.
+// CompileRegexp is the same as regexp.Compile(), except it cached the recently
+// used compiled patterns for performance. Invalid patterns are never cached.
 func CompileRegexp(pattern string) (*Regexp, error) {
-	re := cachedRegexp.GetOrCreate(pattern, func() interface{} {
-		re, err := regexp.Compile(pattern)
-		return &Regexp{
-			Regexp: re,
-			err:    err,
+	re, err := cachedRegexp.GetOrCreateE(pattern, func() (interface{}, error) {
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
 		}
-	}).(*Regexp)
-
-	if re.err != nil {
-		return nil, re.err
+		return &Regexp{Regexp: compiled}, nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	return re, nil
+	return re.(*Regexp), nil
+}
+
+// RegexpCacheStats returns the statistics of the compiled pattern cache.
+func RegexpCacheStats() gomap.CacheStats {
+	return cachedRegexp.Stats()
 }
 
 // MatchString is the same as regexp.MatchString(),
@@ -47,6 +62,25 @@ func (r *Regexp) FindNamedStringSubmatch(s string) map[string]string {
 	if match == nil {
 		return nil
 	}
+	return r.namedSubmatch(match)
+}
+
+// FindAllNamedStringSubmatch is the same as FindNamedStringSubmatch(), except it
+// returns the named groups of all successive matches. A negative n means all
+// the matches.
+func (r *Regexp) FindAllNamedStringSubmatch(s string, n int) []map[string]string {
+	matches := r.FindAllStringSubmatch(s, n)
+	if matches == nil {
+		return nil
+	}
+	ret := make([]map[string]string, len(matches))
+	for i, match := range matches {
+		ret[i] = r.namedSubmatch(match)
+	}
+	return ret
+}
+
+func (r *Regexp) namedSubmatch(match []string) map[string]string {
 	ret := map[string]string{}
 	for i, name := range r.SubexpNames() {
 		if name != "" {
@@ -55,3 +89,118 @@ func (r *Regexp) FindNamedStringSubmatch(s string) map[string]string {
 	}
 	return ret
 }
+
+// RegexpSet is a group of patterns matched against the same input.
+// The input is first scanned by a combination of all the patterns, so inputs
+// matching none of them, which is the most common case in a classifier, are
+// rejected in a single pass. Match() then runs all the patterns side by side
+// in another single pass to find the matching ones, instead of a pass per
+// pattern.
+// Example usage:
+//   set, err := CompileRegexpSet(`^GET `, `timeout`, `(?P<code>5\d\d)`)
+//   for _, idx := range set.Match(line) {
+//       ... set.Patterns()[idx] matched.
+//   }
+type RegexpSet struct {
+	patterns []*Regexp
+	combined *regexp.Regexp
+	prog     *setProg
+}
+
+// RegexpSetMatch is the match result of a single pattern in RegexpSet.
+type RegexpSetMatch struct {
+	// Index of the pattern in the set.
+	Index   int
+	Pattern string
+	// Groups contains the named groups of every match.
+	Groups []map[string]string
+}
+
+// CompileRegexpSet compiles all the patterns into a set.
+func CompileRegexpSet(patterns ...string) (*RegexpSet, error) {
+	set := &RegexpSet{}
+	var alternates []string
+	var trees []*syntax.Regexp
+	for _, pattern := range patterns {
+		re, err := CompileRegexp(pattern)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Compile %s", pattern)
+		}
+		set.patterns = append(set.patterns, re)
+
+		// Captures are removed from the combined pattern, since the same group
+		// name may appear in different patterns.
+		tree, err := syntax.Parse(pattern, syntax.Perl)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Parse %s", pattern)
+		}
+		tree = removeCaptures(tree)
+		alternates = append(alternates, "(?:"+tree.String()+")")
+		trees = append(trees, tree)
+	}
+	prog, err := newSetProg(trees)
+	if err != nil {
+		return nil, errors.Wrap(err, "Compile patterns")
+	}
+	set.prog = prog
+	if len(alternates) > 0 {
+		combined, err := regexp.Compile(strings.Join(alternates, "|"))
+		if err != nil {
+			return nil, errors.Wrap(err, "Compile combined pattern")
+		}
+		set.combined = combined
+	}
+	return set, nil
+}
+
+func removeCaptures(re *syntax.Regexp) *syntax.Regexp {
+	for i, sub := range re.Sub {
+		re.Sub[i] = removeCaptures(sub)
+	}
+	if re.Op == syntax.OpCapture {
+		return re.Sub[0]
+	}
+	return re
+}
+
+// Len returns the number of patterns in the set.
+func (s *RegexpSet) Len() int {
+	return len(s.patterns)
+}
+
+// Patterns returns the patterns in the order of compiling.
+func (s *RegexpSet) Patterns() []string {
+	ret := make([]string, len(s.patterns))
+	for i, re := range s.patterns {
+		ret[i] = re.String()
+	}
+	return ret
+}
+
+// MatchString returns whether any of the patterns matches.
+func (s *RegexpSet) MatchString(str string) bool {
+	return s.combined != nil && s.combined.MatchString(str)
+}
+
+// Match returns the indexes of all the matching patterns in ascending order.
+func (s *RegexpSet) Match(str string) []int {
+	if !s.MatchString(str) {
+		return nil
+	}
+	return s.prog.match(str)
+}
+
+// FindAllNamed returns all the matching patterns, with named groups of every
+// match extracted.
+func (s *RegexpSet) FindAllNamed(str string) []*RegexpSetMatch {
+	var ret []*RegexpSetMatch
+	for _, i := range s.Match(str) {
+		re := s.patterns[i]
+		ret = append(ret, &RegexpSetMatch{
+			Index:   i,
+			Pattern: re.String(),
+			Groups:  re.FindAllNamedStringSubmatch(str, -1),
+		})
+	}
+	return ret
+}
@@ -0,0 +1,176 @@
+package goutils
+
+import (
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	timeType     = reflect.TypeOf(time.Time{})
+)
+
+// ExtractInto fills the struct fields by named groups of the first match, in the
+// struct declaration style.
+// Fields are bound by the `re` tag, and converted to the field type. time.Time
+// fields are parsed in RFC3339 unless given a `layout` tag, and in the configured
+// timezone if the layout has no zone.
+// Example usage:
+//   type Access struct {
+//       Method  string        `re:"method"`
+//       Status  int           `re:"status"`
+//       Elapsed time.Duration `re:"elapsed"`
+//       Time    time.Time     `re:"time" layout:"02/Jan/2006:15:04:05 -0700"`
+//   }
+//   re, _ := CompileRegexp(`\[(?P<time>[^\]]+)\] (?P<method>\w+) (?P<status>\d+) (?P<elapsed>\S+)`)
+//   access := Access{}
+//   matched, err := re.ExtractInto(line, &access)
+// Groups not participating in the match leave the fields untouched.
+func (r *Regexp) ExtractInto(s string, i interface{}) (bool, error) {
+	val := reflect.ValueOf(i)
+	if val.Kind() != reflect.Ptr || val.Elem().Kind() != reflect.Struct {
+		return false, errors.New("Input need to be a ptr to struct")
+	}
+
+	match := r.FindStringSubmatchIndex(s)
+	if match == nil {
+		return false, nil
+	}
+	return true, r.fillStruct(val.Elem(), s, match)
+}
+
+// ExtractAll is the same as ExtractInto(), except it appends a struct to the
+// slice for every successive match.
+// Example usage:
+//   var accesses []*Access
+//   err := re.ExtractAll(logs, &accesses)
+func (r *Regexp) ExtractAll(s string, i interface{}) error {
+	val := reflect.ValueOf(i)
+	if val.Kind() != reflect.Ptr {
+		return errors.New("Input slice need to be a ptr")
+	}
+	if val.Elem().Kind() != reflect.Slice {
+		return errors.New("Input need to be a slice")
+	}
+	elemType := val.Elem().Type().Elem()
+	typ := elemType
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return errors.New("Input need to be a slice of struct")
+	}
+
+	for _, match := range r.FindAllStringSubmatchIndex(s, -1) {
+		inner := reflect.New(typ)
+		if err := r.fillStruct(inner.Elem(), s, match); err != nil {
+			return err
+		}
+		if elemType.Kind() == reflect.Ptr {
+			val.Elem().Set(reflect.Append(val.Elem(), inner))
+		} else {
+			val.Elem().Set(reflect.Append(val.Elem(), inner.Elem()))
+		}
+	}
+	return nil
+}
+
+func (r *Regexp) fillStruct(val reflect.Value, s string, match []int) error {
+	groups := map[string]int{}
+	for i, name := range r.SubexpNames() {
+		if name != "" {
+			groups[name] = i
+		}
+	}
+
+	typ := val.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.PkgPath != "" {
+			// Unexported field will have PkgPath.
+			continue
+		}
+		tag := field.Tag.Get("re")
+		if tag == "" || tag == "-" {
+			continue
+		}
+		idx, hit := groups[tag]
+		if !hit {
+			return errors.Errorf("No group named %s for field %s", tag, field.Name)
+		}
+		if match[2*idx] < 0 {
+			continue
+		}
+		value := s[match[2*idx]:match[2*idx+1]]
+		if err := setExtractedValue(val.Field(i), value, field.Tag.Get("layout")); err != nil {
+			return errors.Wrapf(err, "Field %s", field.Name)
+		}
+	}
+	return nil
+}
+
+func setExtractedValue(v reflect.Value, s, layout string) error {
+	if v.Kind() == reflect.Ptr {
+		elem := reflect.New(v.Type().Elem())
+		if err := setExtractedValue(elem.Elem(), s, layout); err != nil {
+			return err
+		}
+		v.Set(elem)
+		return nil
+	}
+
+	switch v.Type() {
+	case durationType:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	case timeType:
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		t, err := time.ParseInLocation(layout, s, GetTimezone())
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return errors.Errorf("Unsupported type %s", v.Type())
+	}
+	return nil
+}
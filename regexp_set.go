@@ -0,0 +1,174 @@
+package goutils
+
+import (
+	"regexp/syntax"
+	"sync"
+	"unicode/utf8"
+)
+
+// setInst is an instruction of setProg, with the index of the pattern it
+// belongs to.
+type setInst struct {
+	syntax.Inst
+	pattern int
+}
+
+// setProg runs the programs of all the patterns in RegexpSet side by side, in
+// a single pass over the input. It's a Pike VM without captures, which only
+// reports whether each pattern matches anywhere in the input.
+type setProg struct {
+	inst     []setInst
+	starts   []uint32
+	machines sync.Pool
+}
+
+// setMachine is the state of a run, reused by setProg.
+type setMachine struct {
+	clist, nlist *sparseSet
+	matched      []bool
+}
+
+func newSetProg(trees []*syntax.Regexp) (*setProg, error) {
+	p := &setProg{}
+	for i, tree := range trees {
+		prog, err := syntax.Compile(tree.Simplify())
+		if err != nil {
+			return nil, err
+		}
+		offset := uint32(len(p.inst))
+		for _, inst := range prog.Inst {
+			inst.Out += offset
+			if inst.Op == syntax.InstAlt || inst.Op == syntax.InstAltMatch {
+				inst.Arg += offset
+			}
+			p.inst = append(p.inst, setInst{inst, i})
+		}
+		p.starts = append(p.starts, uint32(prog.Start)+offset)
+	}
+	p.machines.New = func() interface{} {
+		return &setMachine{
+			clist:   newSparseSet(len(p.inst)),
+			nlist:   newSparseSet(len(p.inst)),
+			matched: make([]bool, len(p.starts)),
+		}
+	}
+	return p, nil
+}
+
+// match returns the indexes of the matching patterns in ascending order.
+func (p *setProg) match(s string) []int {
+	m := p.machines.Get().(*setMachine)
+	defer p.machines.Put(m)
+	for i := range m.matched {
+		m.matched[i] = false
+	}
+	m.clist.clear()
+	remaining := len(p.starts)
+
+	prev := rune(-1)
+	for pos := 0; remaining > 0; {
+		r, width := rune(-1), 0
+		if pos < len(s) {
+			r, width = utf8.DecodeRuneInString(s[pos:])
+		}
+		ctx := syntax.EmptyOpContext(prev, r)
+		// Unanchored search starts every pattern at every position.
+		for i, pc := range p.starts {
+			if !m.matched[i] {
+				remaining -= p.add(m, m.clist, pc, ctx)
+			}
+		}
+		if r < 0 {
+			break
+		}
+
+		var next rune = -1
+		if pos+width < len(s) {
+			next, _ = utf8.DecodeRuneInString(s[pos+width:])
+		}
+		nextCtx := syntax.EmptyOpContext(r, next)
+		m.nlist.clear()
+		for _, pc := range m.clist.dense {
+			inst := &p.inst[pc]
+			if m.matched[inst.pattern] {
+				continue
+			}
+			var ok bool
+			switch inst.Op {
+			case syntax.InstRune, syntax.InstRune1:
+				ok = inst.MatchRune(r)
+			case syntax.InstRuneAny:
+				ok = true
+			case syntax.InstRuneAnyNotNL:
+				ok = r != '\n'
+			}
+			if ok {
+				remaining -= p.add(m, m.nlist, inst.Out, nextCtx)
+			}
+		}
+		m.clist, m.nlist = m.nlist, m.clist
+		prev = r
+		pos += width
+	}
+
+	var ret []int
+	for i, matched := range m.matched {
+		if matched {
+			ret = append(ret, i)
+		}
+	}
+	return ret
+}
+
+// add follows the empty transitions from pc, and adds the instructions
+// consuming runes to the list. It returns the number of patterns newly
+// matched.
+func (p *setProg) add(m *setMachine, list *sparseSet, pc uint32, ctx syntax.EmptyOp) int {
+	inst := &p.inst[pc]
+	if m.matched[inst.pattern] || list.contains(pc) {
+		return 0
+	}
+	list.insert(pc)
+	switch inst.Op {
+	case syntax.InstAlt, syntax.InstAltMatch:
+		return p.add(m, list, inst.Out, ctx) + p.add(m, list, inst.Arg, ctx)
+	case syntax.InstCapture, syntax.InstNop:
+		return p.add(m, list, inst.Out, ctx)
+	case syntax.InstEmptyWidth:
+		if syntax.EmptyOp(inst.Arg)&^ctx == 0 {
+			return p.add(m, list, inst.Out, ctx)
+		}
+	case syntax.InstMatch:
+		m.matched[inst.pattern] = true
+		return 1
+	}
+	return 0
+}
+
+// sparseSet is a set of instructions with O(1) clear, keeping the order of
+// insertion.
+type sparseSet struct {
+	dense  []uint32
+	sparse []uint32
+}
+
+func newSparseSet(size int) *sparseSet {
+	return &sparseSet{
+		dense:  make([]uint32, 0, size),
+		sparse: make([]uint32, size),
+	}
+}
+
+func (s *sparseSet) contains(pc uint32) bool {
+	i := s.sparse[pc]
+	return int(i) < len(s.dense) && s.dense[i] == pc
+}
+
+func (s *sparseSet) insert(pc uint32) {
+	s.sparse[pc] = uint32(len(s.dense))
+	s.dense = append(s.dense, pc)
+}
+
+func (s *sparseSet) clear() {
+	s.dense = s.dense[:0]
+}
@@ -1,6 +1,11 @@
 package goutils
 
-import "testing"
+import (
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+)
 
 func TestCompileRegexp(t *testing.T) {
 	r1, err := CompileRegexp("abc")
@@ -75,3 +80,148 @@ func TestFindNamedStringSubMatch(t *testing.T) {
 		}
 	}
 }
+
+func TestCompileRegexpError(t *testing.T) {
+	if _, err := CompileRegexp("(abc"); err == nil {
+		t.Error("Expect error compiling invalid pattern")
+	}
+	if cachedRegexp.Exists("(abc") {
+		t.Error("Invalid pattern cached")
+	}
+}
+
+func TestRegexpCacheBound(t *testing.T) {
+	old := cachedRegexp.Capacity()
+	defer cachedRegexp.SetCapacity(old)
+	cachedRegexp.SetCapacity(2)
+
+	for _, p := range []string{"a+", "b+", "c+"} {
+		if _, err := CompileRegexp(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if cachedRegexp.Len() != 2 || cachedRegexp.Exists("a+") {
+		t.Error("Regexp cache not bounded", cachedRegexp.GetKeys())
+	}
+}
+
+func TestRegexpSet(t *testing.T) {
+	set, err := CompileRegexpSet(`^GET `, `timeout`, `(?P<code>5\d\d)`, `(?i)(?P<code>ERROR)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		Input  string
+		Expect []int
+	}{
+		{"GET /index 200", []int{0}},
+		{"POST /api 503 timeout 502", []int{1, 2}},
+		{"error: GET failed", []int{3}},
+		{"nothing", nil},
+	}
+	for _, c := range cases {
+		actual := set.Match(c.Input)
+		if len(actual) != len(c.Expect) {
+			t.Errorf("%s: Expect %v, Actual %v", c.Input, c.Expect, actual)
+			continue
+		}
+		for i := range actual {
+			if actual[i] != c.Expect[i] {
+				t.Errorf("%s: Expect %v, Actual %v", c.Input, c.Expect, actual)
+				break
+			}
+		}
+	}
+
+	matches := set.FindAllNamed("POST /api 503 timeout 502")
+	if len(matches) != 2 || matches[1].Index != 2 {
+		t.Fatalf("Unexpected matches: %v", matches)
+	}
+	groups := matches[1].Groups
+	if len(groups) != 2 || groups[0]["code"] != "503" || groups[1]["code"] != "502" {
+		t.Errorf("Unexpected groups: %v", groups)
+	}
+}
+
+func TestRegexpSetSinglePass(t *testing.T) {
+	patterns := []string{`abc`, `bcd`, `^b`, `d$`, `\bcd\b`, `(?i)ABC`, `x*`, `a.c`, `(?m)^ab`, `c\nd`, `[β-δ]+`, `z|ab(c|x)`}
+	set, err := CompileRegexpSet(patterns...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, input := range []string{"abcd", "cd", "b cd", "ab\ncd", "", "zzz", "αβγ", "\xffabc"} {
+		// Overlapping matches of different patterns are all reported.
+		var expect []int
+		for i, pattern := range patterns {
+			if regexp.MustCompile(pattern).MatchString(input) {
+				expect = append(expect, i)
+			}
+		}
+		if actual := set.Match(input); !reflect.DeepEqual(actual, expect) {
+			t.Errorf("%q: Expect %v, Actual %v", input, expect, actual)
+		}
+	}
+}
+
+type accessLog struct {
+	Method  string        `re:"method"`
+	Status  int           `re:"status"`
+	Ratio   *float64      `re:"ratio"`
+	Elapsed time.Duration `re:"elapsed"`
+	Time    time.Time     `re:"time" layout:"02/Jan/2006:15:04:05 -0700"`
+	Ignored string
+}
+
+func TestExtractInto(t *testing.T) {
+	re, err := CompileRegexp(`\[(?P<time>[^\]]+)\] (?P<method>\w+) (?P<status>\d+)(?: (?P<ratio>[\d.]+))? (?P<elapsed>\S+)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	access := accessLog{}
+	matched, err := re.ExtractInto("[04/Mar/2018:16:05:00 +0000] GET 200 0.5 15ms", &access)
+	if !matched || err != nil {
+		t.Fatal(matched, err)
+	}
+	if access.Method != "GET" || access.Status != 200 || *access.Ratio != 0.5 || access.Elapsed != 15*time.Millisecond {
+		t.Errorf("Unexpected result: %+v", access)
+	}
+	if !access.Time.Equal(time.Date(2018, 3, 4, 16, 5, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected time: %v", access.Time)
+	}
+
+	if matched, _ := re.ExtractInto("nothing", &access); matched {
+		t.Error("Expect not matched")
+	}
+	if _, err := re.ExtractInto("[now] GET 200 15ms", &access); err == nil {
+		t.Error("Expect error parsing time")
+	}
+}
+
+func TestExtractAll(t *testing.T) {
+	re, err := CompileRegexp(`(?P<method>\w+) (?P<status>\d+)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	type result struct {
+		Method string `re:"method"`
+		Status uint16 `re:"status"`
+	}
+
+	var results []*result
+	if err := re.ExtractAll("GET 200, POST 503", &results); err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0].Method != "GET" || results[1].Status != 503 {
+		t.Errorf("Unexpected results: %v", results)
+	}
+
+	var values []result
+	if err := re.ExtractAll("PUT 201", &values); err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != 1 || values[0].Method != "PUT" {
+		t.Errorf("Unexpected results: %v", values)
+	}
+}
@@ -26,9 +26,32 @@ var (
 	requestTimeout = flags.Int("requestTimeout", 10, "Timeout in sec when fetching a remote page.")
 	logAccess      = flags.Bool("logAccess", false, "True to log every requests.")
 	downloadClient *http.Client
-	requestOnce    sync.Once
+	clientLock     sync.RWMutex
 )
 
+func init() {
+	clientFlags := []string{"proxy", "proxyType", "requestTimeout", "logAccess"}
+	flags.Reloadable(clientFlags...)
+	for _, name := range clientFlags {
+		flags.OnChange(name, func() {
+			client, err := newDownloadClient()
+			if err != nil {
+				// Keep the current client for bad configs.
+				LogError("Reload download client", err)
+				return
+			}
+			clientLock.Lock()
+			old := downloadClient
+			downloadClient = client
+			clientLock.Unlock()
+			if old != nil {
+				// In-flight requests are not affected.
+				old.CloseIdleConnections()
+			}
+		})
+	}
+}
+
 func modifiedCheckRedirect(req *http.Request, via []*http.Request) error {
 	if len(via) >= 15 {
 		return errors.New("stopped after 15 redirects")
@@ -50,52 +73,75 @@ func (l *httpLogger) LogResponse(req *http.Request, res *http.Response, err erro
 	LogInfo("[Response]", req.Method, res.StatusCode, duration, req.URL.String())
 }
 
+// GetDownloadClient returns the http client configured by flags. The client is
+// rebuilt once the flags are reloaded.
 func GetDownloadClient() *http.Client {
-	requestOnce.Do(func() {
-		httpTransport := &http.Transport{}
-		if *proxyAddr != "" {
-			proxyURL, err := url.Parse(*proxyAddr)
-			if err != nil {
-				LogFatal("Failed to parse --proxyAddr", err)
-			}
-			if *proxyType == "" {
-				switch proxyURL.Scheme {
-				case "http", "https":
-					*proxyType = "http"
-				case "socks5":
-					*proxyType = "sock5"
-				}
-			}
+	clientLock.RLock()
+	client := downloadClient
+	clientLock.RUnlock()
+	if client != nil {
+		return client
+	}
+
+	clientLock.Lock()
+	defer clientLock.Unlock()
+	if downloadClient == nil {
+		client, err := newDownloadClient()
+		if err != nil {
+			LogFatal(err)
+		}
+		downloadClient = client
+	}
+	return downloadClient
+}
 
-			switch *proxyType {
+// newDownloadClient builds the client by the current flags. The flags are
+// read by flags.Get(), since it's called by GetDownloadClient() as well as the
+// reload callbacks.
+func newDownloadClient() (*http.Client, error) {
+	httpTransport := &http.Transport{}
+	if addr := flags.Get(proxyAddr); addr != "" {
+		proxyURL, err := url.Parse(addr)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to parse --proxy")
+		}
+		typ := flags.Get(proxyType)
+		if typ == "" {
+			switch proxyURL.Scheme {
 			case "http", "https":
-				httpTransport.Proxy = http.ProxyURL(proxyURL)
-				httpTransport.TLSClientConfig = &tls.Config{
-					InsecureSkipVerify: true,
-				}
-			case "sock5", "socks5":
-				dialer, err := proxy.SOCKS5("tcp", proxyURL.Host, nil, proxy.Direct)
-				if err != nil {
-					LogFatal("Failed to dial sock5", err)
-				}
-				httpTransport.Dial = dialer.Dial
-			default:
-				LogFatal("Unknown proxy type:", *proxyType)
+				typ = "http"
+			case "socks5":
+				typ = "sock5"
 			}
 		}
 
-		var roundTripper http.RoundTripper = httpTransport
-
-		if *logAccess {
-			roundTripper = httplogger.NewLoggedTransport(roundTripper, &httpLogger{})
+		switch typ {
+		case "http", "https":
+			httpTransport.Proxy = http.ProxyURL(proxyURL)
+			httpTransport.TLSClientConfig = &tls.Config{
+				InsecureSkipVerify: true,
+			}
+		case "sock5", "socks5":
+			dialer, err := proxy.SOCKS5("tcp", proxyURL.Host, nil, proxy.Direct)
+			if err != nil {
+				return nil, errors.Wrap(err, "Failed to dial sock5")
+			}
+			httpTransport.Dial = dialer.Dial
+		default:
+			return nil, errors.New("Unknown proxy type:" + typ)
 		}
+	}
 
-		downloadClient = &http.Client{Transport: roundTripper}
-		downloadClient.Timeout = time.Duration(*requestTimeout) * time.Second
-		downloadClient.CheckRedirect = modifiedCheckRedirect
-	})
+	var roundTripper http.RoundTripper = httpTransport
 
-	return downloadClient
+	if flags.Get(logAccess) {
+		roundTripper = httplogger.NewLoggedTransport(roundTripper, &httpLogger{})
+	}
+
+	client := &http.Client{Transport: roundTripper}
+	client.Timeout = time.Duration(flags.Get(requestTimeout)) * time.Second
+	client.CheckRedirect = modifiedCheckRedirect
+	return client, nil
 }
 
 func GetWithContext(ctx context.Context, url string) (*http.Response, error) {
@@ -3,83 +3,299 @@ package goutils
 import (
 	"sync"
 	"time"
+
+	"github.com/hoveychen/go-utils/gomap"
 )
 
 var (
+	// ChinaTimezone is a fixed +8 zone. Use GetTimezone() for the timezone
+	// configured by --timezone.
 	ChinaTimezone = time.FixedZone("Asia/Shanghai", 8*60*60) // In case the deploying machine is not in +8 Timezone.
 )
 
+// GetNow returns the current time of the default clock in the configured
+// timezone.
 func GetNow() time.Time {
-	return time.Now().In(ChinaTimezone)
+	return GetClock().Now().In(GetTimezone())
 }
 
-// CronTick returns a channel which trigger every *interval* time.
-// It's almost the same as time.Tick, except it align the start time to integer.
-// For example, an interval of 10 minutes, will start in xx:00:00, xx:10:00, etc.
-func CronTick(interval time.Duration) <-chan struct{} {
-	if interval.Hours() > 24 {
-		LogFatal("CronTick don't accept duration more than 1 day.")
+// CronTicker holds a channel which triggers every *interval* time, aligned to
+// the midnight of the configured timezone. See IntervalSchedule for the alignment.
+type CronTicker struct {
+	C    <-chan struct{}
+	stop chan struct{}
+	once sync.Once
+}
+
+// NewCronTicker returns a CronTicker. Stop() should be called to release the
+// resource once no longer used.
+func NewCronTicker(interval time.Duration) *CronTicker {
+	return NewCronTickerWithClock(interval, GetClock())
+}
+
+// NewCronTickerWithClock is the same as NewCronTicker(), except the time is
+// provided by the given clock.
+func NewCronTickerWithClock(interval time.Duration, clock Clock) *CronTicker {
+	c := make(chan struct{})
+	t := &CronTicker{
+		C:    c,
+		stop: make(chan struct{}),
 	}
-	ticker := make(chan struct{})
+	schedule := &IntervalSchedule{Interval: interval}
 	go func() {
-		now := GetNow()
-		time.Sleep(interval - time.Duration(now.UnixNano())%interval)
-		t := time.NewTicker(interval)
-		defer t.Stop()
-
 		for {
-			ticker <- struct{}{}
-			<-t.C
+			now := clock.Now().In(GetTimezone())
+			timer := clock.NewTimer(schedule.Next(now).Sub(now))
+			select {
+			case <-t.stop:
+				timer.Stop()
+				return
+			case <-timer.C():
+			}
+
+			select {
+			case <-t.stop:
+				return
+			case c <- struct{}{}:
+			}
 		}
 	}()
-	return ticker
+	return t
+}
+
+// Stop turns off the ticker. No more ticks will be sent after Stop.
+func (t *CronTicker) Stop() {
+	t.once.Do(func() {
+		close(t.stop)
+	})
+}
+
+// CronTick returns a channel which trigger every *interval* time.
+// It's almost the same as time.Tick, except it align the start time to integer.
+// For example, an interval of 10 minutes, will start in xx:00:00, xx:10:00, etc.
+// NOTE: The ticker can't be stopped. Use NewCronTicker() if it's not used
+// during the whole process lifetime.
+func CronTick(interval time.Duration) <-chan struct{} {
+	return NewCronTicker(interval).C
 }
 
 // Monitor is a polling helper to get new updates from external source, by comparing the last modified field.
 // Example Usage:
 //   m := NewMonitor(time.Now(), time.Minute)
+//   defer m.Stop()
 //   for {
 //       since := m.Next()
+//       select {
+//       case <-m.Done():
+//           return
+//       default:
+//       }
 //       results := dbQuery(table.updated_at, "$gt", since)
 //       for _, result := range results {
 //           m.Update(result.LastModified)
 //           ... Remaining processing
 //       }
 //   }
+// The progress can be persisted with WithMonitorCheckpoint(), so that polling
+// resumes from where it stopped after restarting.
+//
+// If records may share the same modified time, use NextCursor() and
+// UpdateWithID() instead, which break the tie by record ID, e.g.
+//   cursor := m.NextCursor()
+//   results := dbQuery(mongo.CursorQuery("updated_at", "_id", cursor)).Sort("updated_at", "_id")
+//   for _, result := range results {
+//       if m.Processed(result.LastModified, result.Id) {
+//           continue
+//       }
+//       ... Remaining processing
+//       m.UpdateWithID(result.LastModified, result.Id)
+//   }
 type Monitor struct {
-	since time.Time
-	c     <-chan struct{}
-	first bool
+	cursor Checkpoint
+	ticker *CronTicker
+	first  bool
+	clock  Clock
+	stop   chan struct{}
+	once   sync.Once
+	recent *gomap.LRU
 	sync.RWMutex
+
+	store        CheckpointStore
+	key          string
+	saveInterval time.Duration
+	saved        Checkpoint
+	lastSave     time.Time
+	saveLock     sync.Mutex
+}
+
+const defaultMonitorSaveInterval = time.Second * 10
+
+type MonitorOption func(*Monitor)
+
+// WithMonitorClock sets the clock to schedule polling.
+func WithMonitorClock(clock Clock) MonitorOption {
+	return func(m *Monitor) {
+		m.clock = clock
+	}
+}
+
+// WithMonitorCheckpoint persists the high-water mark to the store under key.
+// NewMonitor() resumes from the saved checkpoint if any.
+func WithMonitorCheckpoint(store CheckpointStore, key string) MonitorOption {
+	return func(m *Monitor) {
+		m.store = store
+		m.key = key
+	}
+}
+
+// WithMonitorSaveInterval sets the minimal interval between two saves of the
+// checkpoint. Defaults to 10 seconds, and 0 means saving on every Update().
+func WithMonitorSaveInterval(interval time.Duration) MonitorOption {
+	return func(m *Monitor) {
+		m.saveInterval = interval
+	}
+}
+
+// WithMonitorIdempotencyWindow remembers the latest *size* records passed to
+// UpdateWithID(), so that Processed() reports the duplicated records, e.g.
+// those re-read by an inclusive query or after a late checkpoint.
+func WithMonitorIdempotencyWindow(size int) MonitorOption {
+	return func(m *Monitor) {
+		m.recent = gomap.NewLRU(size)
+	}
 }
 
 // Update the modified time of last read record.
 func (m *Monitor) Update(t time.Time) {
+	m.UpdateWithID(t, "")
+}
+
+// UpdateWithID updates the cursor by the modified time and ID of the last read
+// record. Records are ordered by time first and then by ID.
+func (m *Monitor) UpdateWithID(t time.Time, id string) {
+	cp := Checkpoint{Time: t, ID: id}
 	m.Lock()
-	defer m.Unlock()
-	if t.After(m.since) {
-		m.since = t
+	if cp.After(m.cursor) {
+		m.cursor = cp
 	}
+	m.Unlock()
+	if m.recent != nil && id != "" {
+		m.recent.Set(recentKey(t, id), true)
+	}
+	m.save(false)
+}
+
+// Processed returns whether the record is in the idempotency window. It's
+// always false without WithMonitorIdempotencyWindow().
+// A record updated again with a new modified time is not regarded processed.
+func (m *Monitor) Processed(t time.Time, id string) bool {
+	return m.recent != nil && m.recent.Exists(recentKey(t, id))
+}
+
+func recentKey(t time.Time, id string) string {
+	return id + "@" + t.UTC().Format(time.RFC3339Nano)
+}
+
+// Cursor returns the current high-water mark.
+func (m *Monitor) Cursor() Checkpoint {
+	m.RLock()
+	defer m.RUnlock()
+	return m.cursor
 }
 
 // Next returns the latest record time to catch up. Any records with update time
 // later than the return value is regarded new unprocessed records.
+// The first call returns immediately, and the following calls wait for the next
+// tick. After Stop(), it returns immediately.
 func (m *Monitor) Next() time.Time {
-	m.RLock()
-	if m.first {
-		m.RUnlock()
-		m.first = false
-		return m.since
+	return m.NextCursor().Time
+}
+
+// NextCursor is the same as Next(), except it returns the composite cursor.
+// Any records later than the cursor time, or at the same time with a greater
+// ID are regarded new. An empty ID means all the records at the cursor time
+// are processed.
+func (m *Monitor) NextCursor() Checkpoint {
+	m.Lock()
+	first := m.first
+	m.first = false
+	m.Unlock()
+
+	if !first {
+		m.save(false)
+		select {
+		case <-m.ticker.C:
+		case <-m.stop:
+		}
 	}
-	m.RUnlock()
-	<-m.c
-	return m.since
+	return m.Cursor()
 }
 
-func NewMonitor(since time.Time, duration time.Duration) (m *Monitor) {
+// Done returns a channel which is closed after Stop().
+func (m *Monitor) Done() <-chan struct{} {
+	return m.stop
+}
+
+// Stop turns off the polling, and saves the latest checkpoint if any.
+func (m *Monitor) Stop() {
+	m.once.Do(func() {
+		close(m.stop)
+		m.ticker.Stop()
+		m.save(true)
+	})
+}
+
+// save persists the high-water mark if it has moved, and the save interval has
+// passed unless forced.
+func (m *Monitor) save(force bool) {
+	if m.store == nil {
+		return
+	}
+	m.saveLock.Lock()
+	defer m.saveLock.Unlock()
+
+	cursor := m.Cursor()
+	if !cursor.After(m.saved) {
+		return
+	}
+	now := m.clock.Now()
+	if !force && now.Sub(m.lastSave) < m.saveInterval {
+		return
+	}
+	if err := m.store.Save(m.key, &cursor); err != nil {
+		// Retry in the next save.
+		LogError("Save checkpoint", m.key, err)
+		return
+	}
+	m.saved = cursor
+	m.lastSave = now
+}
+
+// NewMonitor returns a Monitor polling every *duration* time. since is the
+// initial high-water mark, which is overridden by the saved checkpoint if
+// WithMonitorCheckpoint() is given.
+func NewMonitor(since time.Time, duration time.Duration, opts ...MonitorOption) (m *Monitor) {
 	m = &Monitor{}
-	m.since = since
-	m.c = CronTick(duration)
+	m.cursor = Checkpoint{Time: since}
+	m.clock = GetClock()
+	m.saveInterval = defaultMonitorSaveInterval
+	for _, opt := range opts {
+		opt(m)
+	}
+	if m.store != nil {
+		cp, err := m.store.Load(m.key)
+		if err != nil {
+			// Starting from a wrong place may skip or reprocess lots of records.
+			LogFatal("Load checkpoint", m.key, err)
+		}
+		if cp != nil {
+			m.cursor = *cp
+		}
+		m.saved = m.cursor
+		m.lastSave = m.clock.Now()
+	}
+	m.stop = make(chan struct{})
+	m.ticker = NewCronTickerWithClock(duration, m.clock)
 	m.first = true
 	return
 }
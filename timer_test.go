@@ -1,7 +1,11 @@
 package goutils
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestTimezone(t *testing.T) {
@@ -9,3 +13,164 @@ func TestTimezone(t *testing.T) {
 		t.Fatal("Failed to parse timezone")
 	}
 }
+
+func TestMonitor(t *testing.T) {
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, ChinaTimezone)
+	clock := NewFakeClock(start)
+	m := NewMonitor(start, time.Minute, WithMonitorClock(clock))
+	defer m.Stop()
+
+	if since := m.Next(); !since.Equal(start) {
+		t.Errorf("First Next() = %v, want %v", since, start)
+	}
+	m.Update(start.Add(time.Second))
+	m.Update(start.Add(-time.Second))
+
+	done := make(chan time.Time)
+	go func() {
+		done <- m.Next()
+	}()
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute)
+	if since := <-done; !since.Equal(start.Add(time.Second)) {
+		t.Errorf("Next() = %v, want %v", since, start.Add(time.Second))
+	}
+}
+
+func TestMonitorStop(t *testing.T) {
+	m := NewMonitor(time.Now(), time.Hour)
+	m.Next()
+	go m.Stop()
+	m.Next()
+	select {
+	case <-m.Done():
+	default:
+		t.Error("Done() is not closed after Stop()")
+	}
+	m.Stop()
+}
+
+func TestMonitorCheckpoint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkpoint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	store := NewFileCheckpointStore(filepath.Join(dir, "checkpoints.json"))
+
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, ChinaTimezone)
+	clock := NewFakeClock(start)
+	m := NewMonitor(start, time.Minute,
+		WithMonitorClock(clock),
+		WithMonitorCheckpoint(store, "articles"),
+		WithMonitorSaveInterval(time.Second*10))
+	m.Next()
+
+	m.Update(start.Add(time.Hour))
+	if cp, _ := store.Load("articles"); cp != nil {
+		t.Errorf("Saved before interval: %v", cp.Time)
+	}
+	clock.Advance(time.Second * 10)
+	m.Update(start.Add(time.Hour * 2))
+	cp, err := store.Load("articles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cp == nil || !cp.Time.Equal(start.Add(time.Hour*2)) {
+		t.Errorf("Saved checkpoint = %v, want %v", cp, start.Add(time.Hour*2))
+	}
+
+	m.UpdateWithID(start.Add(time.Hour*3), "5a4a3a00")
+	m.Stop()
+
+	resumed := NewMonitor(start, time.Minute,
+		WithMonitorClock(clock),
+		WithMonitorCheckpoint(store, "articles"))
+	defer resumed.Stop()
+	if cursor := resumed.NextCursor(); !cursor.Time.Equal(start.Add(time.Hour*3)) || cursor.ID != "5a4a3a00" {
+		t.Errorf("Resumed from %v, want 5a4a3a00 at %v", cursor, start.Add(time.Hour*3))
+	}
+
+	if cp, _ := store.Load("others"); cp != nil {
+		t.Errorf("Load(others) = %v, want nil", cp)
+	}
+
+	// A broken file is neither loaded nor overwritten.
+	broken := []byte(`{"articles": {"ti`)
+	if err := ioutil.WriteFile(filepath.Join(dir, "checkpoints.json"), broken, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Load("articles"); err == nil {
+		t.Error("No error to load broken file")
+	}
+	if err := store.Save("others", &Checkpoint{Time: start}); err == nil {
+		t.Error("No error to save into broken file")
+	}
+	if data, _ := ioutil.ReadFile(filepath.Join(dir, "checkpoints.json")); string(data) != string(broken) {
+		t.Errorf("Broken file is overwritten: %s", data)
+	}
+}
+
+func TestMonitorTieBreak(t *testing.T) {
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, ChinaTimezone)
+	clock := NewFakeClock(start)
+	m := NewMonitor(start, time.Minute, WithMonitorClock(clock), WithMonitorIdempotencyWindow(3))
+	defer m.Stop()
+
+	m.NextCursor()
+	// Records at the initial time are regarded processed.
+	m.UpdateWithID(start, "x")
+	if cursor := m.Cursor(); !cursor.Time.Equal(start) || cursor.ID != "" {
+		t.Errorf("UpdateWithID() at the initial time moved cursor backward to %v", cursor)
+	}
+	m.UpdateWithID(start.Add(time.Second), "b")
+	m.UpdateWithID(start.Add(time.Second), "a")
+	if cursor := m.Cursor(); !cursor.Time.Equal(start.Add(time.Second)) || cursor.ID != "b" {
+		t.Errorf("Cursor() = %v, want b at %v", cursor, start.Add(time.Second))
+	}
+	// Update() means all the records at the time are processed.
+	m.Update(start.Add(time.Second))
+	if cursor := m.Cursor(); !cursor.Time.Equal(start.Add(time.Second)) || cursor.ID != "" {
+		t.Errorf("Cursor() = %v, want %v", cursor, start.Add(time.Second))
+	}
+	m.UpdateWithID(start.Add(time.Second), "c")
+	if cursor := m.Cursor(); cursor.ID != "" {
+		t.Errorf("UpdateWithID() at the same time moved cursor backward to %v", cursor)
+	}
+	m.Update(start.Add(time.Second * 2))
+	if cursor := m.Cursor(); !cursor.Time.Equal(start.Add(time.Second*2)) || cursor.ID != "" {
+		t.Errorf("Cursor() = %v, want %v", cursor, start.Add(time.Second*2))
+	}
+
+	if !m.Processed(start.Add(time.Second), "a") || !m.Processed(start.Add(time.Second), "b") {
+		t.Error("Processed records are not in the window")
+	}
+	if m.Processed(start.Add(time.Second*3), "a") {
+		t.Error("Record updated again is regarded processed")
+	}
+	m.UpdateWithID(start.Add(time.Second*3), "c")
+	if m.Processed(start.Add(time.Second), "b") {
+		t.Error("Record out of the window is regarded processed")
+	}
+}
+
+func TestCheckpointAfter(t *testing.T) {
+	now := time.Now()
+	cases := []struct {
+		a, b Checkpoint
+		want bool
+	}{
+		{Checkpoint{Time: now.Add(time.Second)}, Checkpoint{Time: now, ID: "z"}, true},
+		{Checkpoint{Time: now, ID: "b"}, Checkpoint{Time: now, ID: "a"}, true},
+		{Checkpoint{Time: now, ID: "a"}, Checkpoint{Time: now, ID: "a"}, false},
+		{Checkpoint{Time: now}, Checkpoint{Time: now, ID: "z"}, true},
+		{Checkpoint{Time: now, ID: "z"}, Checkpoint{Time: now}, false},
+		{Checkpoint{Time: now}, Checkpoint{Time: now}, false},
+		{Checkpoint{Time: now, ID: "10"}, Checkpoint{Time: now, ID: "9"}, false},
+	}
+	for _, c := range cases {
+		if got := c.a.After(c.b); got != c.want {
+			t.Errorf("%v.After(%v) = %v, want %v", c.a, c.b, got, c.want)
+		}
+	}
+}
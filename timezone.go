@@ -0,0 +1,108 @@
+package goutils
+
+import (
+	"sync"
+	"time"
+	// Embedded tzdata is used as a fallback if the deploying machine lacks of
+	// zoneinfo, e.g. in a minimal docker image.
+	_ "time/tzdata"
+
+	"github.com/hoveychen/go-utils/flags"
+)
+
+var (
+	timezone = flags.String("timezone", "", "IANA timezone name used by GetNow() and time alignments, e.g. America/New_York. The fixed +8 ChinaTimezone if empty.")
+
+	configuredTimezone = ChinaTimezone
+	timezoneLock       sync.RWMutex
+)
+
+func init() {
+	PkgInit(func() {
+		if *timezone == "" {
+			return
+		}
+		loc, err := time.LoadLocation(*timezone)
+		if err != nil {
+			LogFatal("Failed to load --timezone", *timezone, err)
+		}
+		SetTimezone(loc)
+	})
+}
+
+// GetTimezone returns the configured timezone by --timezone. It's ChinaTimezone
+// if not configured, or before Init().
+func GetTimezone() *time.Location {
+	timezoneLock.RLock()
+	defer timezoneLock.RUnlock()
+	return configuredTimezone
+}
+
+// SetTimezone overrides the configured timezone.
+func SetTimezone(loc *time.Location) {
+	timezoneLock.Lock()
+	defer timezoneLock.Unlock()
+	configuredTimezone = loc
+}
+
+// midnight returns the first instant of the day, in case the midnight is
+// skipped by the daylight saving time.
+func midnight(year int, month time.Month, day int, loc *time.Location) time.Time {
+	t := time.Date(year, month, day, 0, 0, 0, 0, loc)
+	if t.Hour() > 12 {
+		// Normalized backward to the previous day.
+		t = t.Add(time.Duration(24-t.Hour()) * time.Hour)
+	}
+	return t
+}
+
+func inTimezone(t time.Time, loc *time.Location) time.Time {
+	if loc == nil {
+		loc = GetTimezone()
+	}
+	return t.In(loc)
+}
+
+// StartOfDay returns the first instant of the day containing t in the given
+// location. A nil location means the configured timezone.
+func StartOfDay(t time.Time, loc *time.Location) time.Time {
+	t = inTimezone(t, loc)
+	return midnight(t.Year(), t.Month(), t.Day(), t.Location())
+}
+
+// EndOfDay returns the last instant of the day containing t in the given
+// location. A nil location means the configured timezone.
+func EndOfDay(t time.Time, loc *time.Location) time.Time {
+	t = inTimezone(t, loc)
+	return midnight(t.Year(), t.Month(), t.Day()+1, t.Location()).Add(-time.Nanosecond)
+}
+
+// StartOfWeek returns the first instant of the week containing t in the given
+// location. Weeks start on Monday. A nil location means the configured timezone.
+func StartOfWeek(t time.Time, loc *time.Location) time.Time {
+	t = inTimezone(t, loc)
+	offset := (int(t.Weekday()) + 6) % 7
+	return midnight(t.Year(), t.Month(), t.Day()-offset, t.Location())
+}
+
+// EndOfWeek returns the last instant of the week containing t in the given
+// location. Weeks end on Sunday. A nil location means the configured timezone.
+func EndOfWeek(t time.Time, loc *time.Location) time.Time {
+	t = inTimezone(t, loc)
+	offset := (int(t.Weekday()) + 6) % 7
+	return midnight(t.Year(), t.Month(), t.Day()-offset+7, t.Location()).Add(-time.Nanosecond)
+}
+
+// StartOfMonth returns the first instant of the month containing t in the given
+// location. A nil location means the configured timezone.
+func StartOfMonth(t time.Time, loc *time.Location) time.Time {
+	t = inTimezone(t, loc)
+	return midnight(t.Year(), t.Month(), 1, t.Location())
+}
+
+// EndOfMonth returns the last instant of the month containing t in the given
+// location. A nil location means the configured timezone.
+func EndOfMonth(t time.Time, loc *time.Location) time.Time {
+	t = inTimezone(t, loc)
+	return midnight(t.Year(), t.Month()+1, 1, t.Location()).Add(-time.Nanosecond)
+}
@@ -0,0 +1,65 @@
+package goutils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDayBoundaries(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 2018-03-07 02:00 UTC is Tuesday 21:00 in New York, and Wednesday 10:00 in Shanghai.
+	ts := time.Date(2018, 3, 7, 2, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		Name   string
+		Fn     func(time.Time, *time.Location) time.Time
+		Loc    *time.Location
+		Expect time.Time
+	}{
+		{"StartOfDay", StartOfDay, ny, time.Date(2018, 3, 6, 0, 0, 0, 0, ny)},
+		{"EndOfDay", EndOfDay, ny, time.Date(2018, 3, 6, 23, 59, 59, 999999999, ny)},
+		{"StartOfWeek", StartOfWeek, ny, time.Date(2018, 3, 5, 0, 0, 0, 0, ny)},
+		{"EndOfWeek", EndOfWeek, ny, time.Date(2018, 3, 11, 23, 59, 59, 999999999, ny)},
+		{"StartOfMonth", StartOfMonth, ny, time.Date(2018, 3, 1, 0, 0, 0, 0, ny)},
+		{"EndOfMonth", EndOfMonth, ny, time.Date(2018, 3, 31, 23, 59, 59, 999999999, ny)},
+		{"StartOfDayDefault", StartOfDay, nil, time.Date(2018, 3, 7, 0, 0, 0, 0, ChinaTimezone)},
+	}
+	for _, c := range cases {
+		if actual := c.Fn(ts, c.Loc); !actual.Equal(c.Expect) {
+			t.Errorf("%s: Expect %v, Actual %v", c.Name, c.Expect, actual)
+		}
+	}
+
+	// The day DST starts only has 23 hours.
+	day := time.Date(2018, 3, 11, 12, 0, 0, 0, ny)
+	if d := EndOfDay(day, ny).Sub(StartOfDay(day, ny)); d != 23*time.Hour-time.Nanosecond {
+		t.Errorf("Unexpected length of day: %v", d)
+	}
+}
+
+func TestSetTimezone(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := GetTimezone()
+	defer SetTimezone(old)
+	SetTimezone(ny)
+	if GetNow().Location() != ny {
+		t.Error("GetNow not in the configured timezone")
+	}
+}
+
+func TestDefaultTimezone(t *testing.T) {
+	if GetTimezone() != ChinaTimezone {
+		t.Fatalf("Default timezone = %v, want ChinaTimezone", GetTimezone())
+	}
+	// The fixed zone has no DST, unlike Asia/Shanghai in 1986-1991.
+	ts := time.Date(1988, 7, 1, 0, 30, 0, 0, time.UTC)
+	if start := StartOfDay(ts, nil); !start.Equal(time.Date(1988, 7, 1, 0, 0, 0, 0, ChinaTimezone)) {
+		t.Errorf("StartOfDay() = %v", start)
+	}
+}
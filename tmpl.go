@@ -3,66 +3,176 @@ package goutils
 import (
 	"bytes"
 	htmlTemplate "html/template"
+	"regexp"
+	"strconv"
 	"text/template"
 
+	"github.com/hoveychen/go-utils/flags"
 	"github.com/hoveychen/go-utils/gomap"
 )
 
 var (
-	textTmplCache = gomap.New()
-	htmlTmplCache = gomap.New()
+	templateCacheSize = flags.Int("templateCacheSize", 1000, "Max number of parsed inline templates to cache. Non-positive for no bound.")
+
+	textTmplCache = gomap.NewLRU(*templateCacheSize)
+	htmlTmplCache = gomap.NewLRU(*templateCacheSize)
+
+	templateErrorPattern = regexp.MustCompile(`template: ([^:]*):(\d+)(?::(\d+))?: `)
 )
 
+func init() {
+	PkgInit(func() {
+		textTmplCache.SetCapacity(*templateCacheSize)
+		htmlTmplCache.SetCapacity(*templateCacheSize)
+	})
+}
+
 type Var map[string]interface{}
 
-func Sprintt(textTmpl string, data interface{}) string {
-	ret := textTmplCache.GetOrCreate(textTmpl, func() interface{} {
-		tpl, err := template.New("test").Parse(textTmpl)
-		if err != nil {
-			LogError(err)
-			return nil
-		}
-		return tpl
-	})
+// TemplateError is returned when an inline template fails to parse or execute.
+// Column is 0 if the position is not reported by the template package,
+// e.g. most of the parse errors.
+type TemplateError struct {
+	Name   string
+	Line   int
+	Column int
+	Err    error
+}
 
-	if ret == nil {
-		// Not valid text template.
-		return ""
+func (e *TemplateError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *TemplateError) Unwrap() error {
+	return e.Err
+}
+
+func newTemplateError(err error) error {
+	ret := &TemplateError{Err: err}
+	if m := templateErrorPattern.FindStringSubmatch(err.Error()); m != nil {
+		ret.Name = m[1]
+		ret.Line, _ = strconv.Atoi(m[2])
+		ret.Column, _ = strconv.Atoi(m[3])
 	}
+	return ret
+}
 
-	tmpl := (ret).(*template.Template)
+func templateCacheKey(tmpl string, strict bool) string {
+	if strict {
+		return "strict:" + tmpl
+	}
+	return "lax:" + tmpl
+}
+
+func getTextTemplate(textTmpl string, strict bool) (*template.Template, error) {
+	key := templateCacheKey(textTmpl, strict)
+	if tpl := textTmplCache.Get(key); tpl != nil {
+		return tpl.(*template.Template), nil
+	}
+
+	tpl := template.New("test").Funcs(textTemplateFuncs())
+	if strict {
+		tpl = tpl.Option("missingkey=error")
+	}
+	tpl, err := tpl.Parse(textTmpl)
+	if err != nil {
+		// Invalid templates are not cached, in case they come from user input.
+		return nil, newTemplateError(err)
+	}
+	textTmplCache.Set(key, tpl)
+	return tpl, nil
+}
+
+func getHTMLTemplate(htmlTmpl string, strict bool) (*htmlTemplate.Template, error) {
+	key := templateCacheKey(htmlTmpl, strict)
+	if tpl := htmlTmplCache.Get(key); tpl != nil {
+		return tpl.(*htmlTemplate.Template), nil
+	}
+
+	tpl := htmlTemplate.New("test").Funcs(htmlTemplateFuncs())
+	if strict {
+		tpl = tpl.Option("missingkey=error")
+	}
+	tpl, err := tpl.Parse(htmlTmpl)
+	if err != nil {
+		return nil, newTemplateError(err)
+	}
+	htmlTmplCache.Set(key, tpl)
+	return tpl, nil
+}
+
+func renderText(textTmpl string, data interface{}, strict bool) (string, error) {
+	tmpl, err := getTextTemplate(textTmpl, strict)
+	if err != nil {
+		return "", err
+	}
 	buf := &bytes.Buffer{}
-	err := tmpl.Execute(buf, data)
+	if err := tmpl.Execute(buf, data); err != nil {
+		return "", newTemplateError(err)
+	}
+	return buf.String(), nil
+}
+
+func renderHTML(htmlTmpl string, data interface{}, strict bool) (string, error) {
+	tmpl, err := getHTMLTemplate(htmlTmpl, strict)
 	if err != nil {
-		LogError(err)
-		return ""
+		return "", err
+	}
+	buf := &bytes.Buffer{}
+	if err := tmpl.Execute(buf, data); err != nil {
+		return "", newTemplateError(err)
 	}
+	return buf.String(), nil
+}
 
-	return buf.String()
+// RenderText applies the text template to data. Any parse or execute error is
+// returned as *TemplateError.
+func RenderText(textTmpl string, data interface{}) (string, error) {
+	return renderText(textTmpl, data, false)
 }
 
-func SprintHTML(htmlTmpl string, data interface{}) string {
-	ret := htmlTmplCache.GetOrCreate(htmlTmpl, func() interface{} {
-		tpl, err := htmlTemplate.New("test").Parse(htmlTmpl)
-		if err != nil {
-			LogError(err)
-			return nil
-		}
-		return tpl
-	})
+// RenderTextStrict is the same as RenderText(), except missing map keys are
+// regarded as errors instead of "<no value>".
+func RenderTextStrict(textTmpl string, data interface{}) (string, error) {
+	return renderText(textTmpl, data, true)
+}
+
+// RenderHTML applies the html template to data. Any parse or execute error is
+// returned as *TemplateError.
+func RenderHTML(htmlTmpl string, data interface{}) (string, error) {
+	return renderHTML(htmlTmpl, data, false)
+}
+
+// RenderHTMLStrict is the same as RenderHTML(), except missing map keys are
+// regarded as errors instead of empty output.
+func RenderHTMLStrict(htmlTmpl string, data interface{}) (string, error) {
+	return renderHTML(htmlTmpl, data, true)
+}
+
+// TemplateCacheStats returns the statistics of the inline template cache for
+// text and html templates respectively.
+func TemplateCacheStats() (text, html gomap.CacheStats) {
+	return textTmplCache.Stats(), htmlTmplCache.Stats()
+}
 
-	if ret == nil {
-		// Not valid html template.
+// Sprintt is the same as RenderText(), except any error is logged and an empty
+// string is returned.
+func Sprintt(textTmpl string, data interface{}) string {
+	ret, err := RenderText(textTmpl, data)
+	if err != nil {
+		LogError(err)
 		return ""
 	}
+	return ret
+}
 
-	tmpl := (ret).(*htmlTemplate.Template)
-	buf := &bytes.Buffer{}
-	err := tmpl.Execute(buf, data)
+// SprintHTML is the same as RenderHTML(), except any error is logged and an
+// empty string is returned.
+func SprintHTML(htmlTmpl string, data interface{}) string {
+	ret, err := RenderHTML(htmlTmpl, data)
 	if err != nil {
 		LogError(err)
 		return ""
 	}
-
-	return buf.String()
+	return ret
 }
@@ -0,0 +1,332 @@
+package goutils
+
+import (
+	"encoding/json"
+	"fmt"
+	htmlTemplate "html/template"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	templateFuncLock sync.RWMutex
+	templateFuncs    = map[string]interface{}{
+		"date":     tmplDate,
+		"dateIn":   tmplDateIn,
+		"number":   tmplNumber,
+		"percent":  tmplPercent,
+		"currency": tmplCurrency,
+		"duration": tmplDuration,
+		"bytes":    tmplBytes,
+		"truncate": tmplTruncate,
+		"title":    ToTitle,
+		"upper":    strings.ToUpper,
+		"lower":    strings.ToLower,
+		"trim":     strings.TrimSpace,
+		"contains": tmplContains,
+		"replace":  tmplReplace,
+		"join":     tmplJoin,
+		"json":     tmplJson,
+		"default":  tmplDefault,
+		"coalesce": tmplCoalesce,
+	}
+)
+
+// RegisterTemplateFuncs adds functions to the default function library shared
+// by Sprintt(), SprintHTML() and TemplateSet. Functions with the same name are
+// overridden.
+// NOTE: Templates are cached once parsed, so it should be called before any
+// template using the functions is parsed, e.g. in init().
+func RegisterTemplateFuncs(funcs map[string]interface{}) {
+	templateFuncLock.Lock()
+	defer templateFuncLock.Unlock()
+	for name, fn := range funcs {
+		templateFuncs[name] = fn
+	}
+}
+
+func textTemplateFuncs() template.FuncMap {
+	templateFuncLock.RLock()
+	defer templateFuncLock.RUnlock()
+	ret := template.FuncMap{}
+	for name, fn := range templateFuncs {
+		ret[name] = fn
+	}
+	return ret
+}
+
+func htmlTemplateFuncs() htmlTemplate.FuncMap {
+	ret := textTemplateFuncs()
+	// Mark the json output as safe, so that it can be embedded in <script> as is.
+	ret["json"] = func(v interface{}) (htmlTemplate.JS, error) {
+		s, err := tmplJson(v)
+		return htmlTemplate.JS(s), err
+	}
+	return ret
+}
+
+func toTemplateTime(v interface{}) (time.Time, error) {
+	switch t := v.(type) {
+	case time.Time:
+		return t, nil
+	case *time.Time:
+		if t == nil {
+			return time.Time{}, nil
+		}
+		return *t, nil
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return time.Unix(rv.Int(), 0), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return time.Unix(int64(rv.Uint()), 0), nil
+	}
+	return time.Time{}, fmt.Errorf("Unexpected time value: %v", v)
+}
+
+func toTemplateFloat(v interface{}) (float64, error) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), nil
+	case reflect.String:
+		return strconv.ParseFloat(rv.String(), 64)
+	}
+	return 0, fmt.Errorf("Unexpected number value: %v", v)
+}
+
+// tmplDate formats the time in the configured timezone. Unix seconds are accepted as well.
+// Example: {{.CreatedAt | date "2006-01-02 15:04"}}
+func tmplDate(layout string, v interface{}) (string, error) {
+	t, err := toTemplateTime(v)
+	if err != nil {
+		return "", err
+	}
+	return t.In(GetTimezone()).Format(layout), nil
+}
+
+// tmplDateIn formats the time in the given IANA timezone.
+// Example: {{.CreatedAt | dateIn "America/New_York" "2006-01-02 15:04"}}
+func tmplDateIn(zone, layout string, v interface{}) (string, error) {
+	loc, err := time.LoadLocation(zone)
+	if err != nil {
+		return "", err
+	}
+	t, err := toTemplateTime(v)
+	if err != nil {
+		return "", err
+	}
+	return t.In(loc).Format(layout), nil
+}
+
+// formatNumber formats the number with thousands separators, e.g. 1,234.50
+func formatNumber(f float64, decimals int) string {
+	if decimals < 0 {
+		decimals = 0
+	}
+	s := strconv.FormatFloat(math.Abs(f), 'f', decimals, 64)
+	intPart, fracPart := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		intPart, fracPart = s[:i], s[i:]
+	}
+
+	var parts []string
+	for len(intPart) > 3 {
+		parts = append([]string{intPart[len(intPart)-3:]}, parts...)
+		intPart = intPart[:len(intPart)-3]
+	}
+	parts = append([]string{intPart}, parts...)
+
+	sign := ""
+	if f < 0 && strings.Trim(s, "0.") != "" {
+		sign = "-"
+	}
+	return sign + strings.Join(parts, ",") + fracPart
+}
+
+// tmplNumber formats the number with thousands separators.
+// Example: {{.Amount | number 2}} => 1,234.50
+func tmplNumber(decimals int, v interface{}) (string, error) {
+	f, err := toTemplateFloat(v)
+	if err != nil {
+		return "", err
+	}
+	return formatNumber(f, decimals), nil
+}
+
+// tmplPercent formats the ratio as percentage.
+// Example: {{.Ratio | percent 1}} => 12.3%
+func tmplPercent(decimals int, v interface{}) (string, error) {
+	f, err := toTemplateFloat(v)
+	if err != nil {
+		return "", err
+	}
+	return formatNumber(f*100, decimals) + "%", nil
+}
+
+// tmplCurrency formats the number as money with 2 decimals.
+// Example: {{.Price | currency "¥"}} => ¥1,234.50
+func tmplCurrency(symbol string, v interface{}) (string, error) {
+	f, err := toTemplateFloat(v)
+	if err != nil {
+		return "", err
+	}
+	s := formatNumber(f, 2)
+	if strings.HasPrefix(s, "-") {
+		return "-" + symbol + s[1:], nil
+	}
+	return symbol + s, nil
+}
+
+// tmplDuration humanizes the duration, e.g. 2d 3h, 5m 10s. Numbers are
+// regarded as seconds.
+func tmplDuration(v interface{}) (string, error) {
+	d, ok := v.(time.Duration)
+	if !ok {
+		f, err := toTemplateFloat(v)
+		if err != nil {
+			return "", err
+		}
+		d = time.Duration(f * float64(time.Second))
+	}
+	return humanizeDuration(d), nil
+}
+
+func humanizeDuration(d time.Duration) string {
+	if d < 0 {
+		return "-" + humanizeDuration(-d)
+	}
+	if d < time.Second {
+		return d.String()
+	}
+	units := []struct {
+		suffix string
+		size   time.Duration
+	}{
+		{"d", 24 * time.Hour},
+		{"h", time.Hour},
+		{"m", time.Minute},
+		{"s", time.Second},
+	}
+	var parts []string
+	for _, u := range units {
+		if d >= u.size {
+			parts = append(parts, fmt.Sprintf("%d%s", d/u.size, u.suffix))
+			d %= u.size
+		}
+		// Two most significant units are precise enough for human.
+		if len(parts) == 2 {
+			break
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// tmplBytes humanizes the size in bytes, e.g. 1.5 MB.
+func tmplBytes(v interface{}) (string, error) {
+	f, err := toTemplateFloat(v)
+	if err != nil {
+		return "", err
+	}
+	units := []string{"B", "KB", "MB", "GB", "TB", "PB"}
+	i := 0
+	for math.Abs(f) >= 1024 && i < len(units)-1 {
+		f /= 1024
+		i++
+	}
+	if i == 0 {
+		return fmt.Sprintf("%d %s", int64(f), units[i]), nil
+	}
+	return strconv.FormatFloat(f, 'f', 1, 64) + " " + units[i], nil
+}
+
+// tmplTruncate cuts the string to at most n characters, with "..." appended
+// if truncated.
+// Example: {{.Title | truncate 20}}
+func tmplTruncate(n int, s string) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	if n <= 3 {
+		return string([]rune(s)[:n])
+	}
+	return string([]rune(s)[:n-3]) + "..."
+}
+
+func tmplContains(substr, s string) bool {
+	return strings.Contains(s, substr)
+}
+
+func tmplReplace(old, new, s string) string {
+	return strings.Replace(s, old, new, -1)
+}
+
+// tmplJoin concatenates any kind of slice with separator.
+// Example: {{.Tags | join ", "}}
+func tmplJoin(sep string, v interface{}) (string, error) {
+	if s, ok := v.([]string); ok {
+		return strings.Join(s, sep), nil
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return "", fmt.Errorf("Unexpected slice value: %v", v)
+	}
+	parts := make([]string, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		parts[i] = fmt.Sprint(rv.Index(i).Interface())
+	}
+	return strings.Join(parts, sep), nil
+}
+
+// tmplJson encodes the value into json. The output is safe to be embedded
+// into html, since <, > and & are escaped.
+func tmplJson(v interface{}) (string, error) {
+	d, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(d), nil
+}
+
+func isZeroTemplateValue(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Array, reflect.String:
+		return rv.Len() == 0
+	}
+	return rv.IsZero()
+}
+
+// tmplDefault returns the given value unless it's empty or zero.
+// Example: {{.Nickname | default "Anonymous"}}
+func tmplDefault(def, v interface{}) interface{} {
+	if isZeroTemplateValue(v) {
+		return def
+	}
+	return v
+}
+
+// tmplCoalesce returns the first non-empty value.
+// Example: {{coalesce .Nickname .Name "Anonymous"}}
+func tmplCoalesce(values ...interface{}) interface{} {
+	for _, v := range values {
+		if !isZeroTemplateValue(v) {
+			return v
+		}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package goutils
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTemplateFuncs(t *testing.T) {
+	ts := time.Date(2018, 3, 4, 16, 5, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		tmpl string
+		data interface{}
+		want string
+	}{
+		{"Date", `{{.T | date "2006-01-02 15:04"}}`, Var{"T": ts}, "2018-03-05 00:05"},
+		{"DateIn", `{{.T | dateIn "UTC" "2006-01-02 15:04"}}`, Var{"T": ts.Unix()}, "2018-03-04 16:05"},
+		{"Number", `{{.N | number 2}}`, Var{"N": 1234567.891}, "1,234,567.89"},
+		{"NegativeNumber", `{{.N | number 0}}`, Var{"N": -1234}, "-1,234"},
+		{"Percent", `{{.N | percent 1}}`, Var{"N": 0.1234}, "12.3%"},
+		{"Currency", `{{.N | currency "$"}}`, Var{"N": -1234.5}, "-$1,234.50"},
+		{"Duration", `{{.D | duration}}`, Var{"D": 26*time.Hour + 3*time.Minute}, "1d 2h"},
+		{"DurationSeconds", `{{.D | duration}}`, Var{"D": 65}, "1m 5s"},
+		{"Bytes", `{{.N | bytes}} {{.M | bytes}}`, Var{"N": 1536 * 1024, "M": 100}, "1.5 MB 100 B"},
+		{"Truncate", `{{.S | truncate 8}}`, Var{"S": "hello world"}, "hello..."},
+		{"Title", `{{.S | title}}`, Var{"S": "women's clothes"}, "Women's Clothes"},
+		{"Join", `{{.L | join ","}}`, Var{"L": []int{1, 2, 3}}, "1,2,3"},
+		{"Json", `{{.V | json}}`, Var{"V": Var{"a": "<b>"}}, `{"a":"\u003cb\u003e"}`},
+		{"Default", `{{.S | default "n/a"}}`, Var{"S": ""}, "n/a"},
+		{"Coalesce", `{{coalesce .A .B "c"}}`, Var{"A": "", "B": 0}, "c"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Sprintt(tt.tmpl, tt.data); got != tt.want {
+				t.Errorf("Sprintt() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTMLTemplateJson(t *testing.T) {
+	got := SprintHTML(`<script>var v = {{.V | json}};</script>`, Var{"V": Var{"a": "</script>"}})
+	want := `<script>var v = {"a":"\u003c/script\u003e"};</script>`
+	if got != want {
+		t.Errorf("SprintHTML() = %v, want %v", got, want)
+	}
+}
+
+func TestRegisterTemplateFuncs(t *testing.T) {
+	RegisterTemplateFuncs(map[string]interface{}{
+		"shout": func(s string) string { return strings.ToUpper(s) + "!" },
+	})
+	if got := Sprintt(`{{.S | shout}}`, Var{"S": "hi"}); got != "HI!" {
+		t.Errorf("Sprintt() = %v, want HI!", got)
+	}
+}
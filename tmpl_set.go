@@ -0,0 +1,311 @@
+package goutils
+
+import (
+	htmlTemplate "html/template"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// TemplateSet is a collection of templates loaded from local files or remote
+// URIs, where every page shares the same layouts and partials.
+// Each page is compiled together with all the layouts, so a page can override
+// the blocks declared in a layout without affecting the other pages.
+// Example usage:
+//   ts := NewTemplateSet(
+//       WithTemplateLayouts("tmpl/layouts/*.html", "tmpl/partials/*.html"),
+//       WithTemplatePages("tmpl/pages/*.html"),
+//       WithTemplateHTML(true),
+//   )
+//   if err := ts.Load(); err != nil {
+//       ...
+//   }
+//   err := ts.ExecuteLayout(w, "base.html", "report.html", data)
+//
+//   >base.html
+//   <body>{{block "content" .}}{{end}}</body>
+//   >report.html
+//   {{define "content"}}<p>{{.Title}}</p>{{end}}
+//
+// In debug mode, local files are checked before every execution and the whole
+// set is reloaded once any of them is modified, added or removed.
+type TemplateSet struct {
+	sync.RWMutex
+	html           bool
+	layoutPatterns []string
+	pagePatterns   []string
+	funcs          map[string]interface{}
+
+	layouts []*templateSource
+	pages   map[string]*templateSource
+	// tmpls is keyed by page name, where the empty key holds the layouts only.
+	tmpls map[string]templateExecutor
+}
+
+type templateSource struct {
+	name    string
+	path    string
+	content string
+	// modTime is zero for remote sources, which are never reloaded.
+	modTime time.Time
+}
+
+type templateExecutor interface {
+	ExecuteTemplate(w io.Writer, name string, data interface{}) error
+}
+
+type TemplateSetOption func(*TemplateSet)
+
+// WithTemplateHTML sets whether the templates are parsed by html/template,
+// which escapes the output according to the context.
+func WithTemplateHTML(html bool) TemplateSetOption {
+	return func(ts *TemplateSet) {
+		ts.html = html
+	}
+}
+
+// WithTemplateLayouts adds the glob patterns or remote URIs of templates shared
+// by all the pages, like layouts and partials.
+func WithTemplateLayouts(patterns ...string) TemplateSetOption {
+	return func(ts *TemplateSet) {
+		ts.layoutPatterns = append(ts.layoutPatterns, patterns...)
+	}
+}
+
+// WithTemplatePages adds the glob patterns or remote URIs of page templates.
+func WithTemplatePages(patterns ...string) TemplateSetOption {
+	return func(ts *TemplateSet) {
+		ts.pagePatterns = append(ts.pagePatterns, patterns...)
+	}
+}
+
+// WithTemplateFuncs adds functions to be called inside the templates, besides
+// the default function library.
+func WithTemplateFuncs(funcs map[string]interface{}) TemplateSetOption {
+	return func(ts *TemplateSet) {
+		for name, fn := range funcs {
+			ts.funcs[name] = fn
+		}
+	}
+}
+
+// NewTemplateSet returns a template set. Load() must be called before any usage.
+func NewTemplateSet(opts ...TemplateSetOption) *TemplateSet {
+	ts := &TemplateSet{
+		funcs: map[string]interface{}{},
+	}
+	for _, opt := range opts {
+		opt(ts)
+	}
+	return ts
+}
+
+// Load reads and parses all the templates. It's safe to call it again to
+// reload the templates.
+func (ts *TemplateSet) Load() error {
+	layouts, err := loadTemplateSources(ts.layoutPatterns)
+	if err != nil {
+		return errors.Wrap(err, "Load layouts")
+	}
+	pageSources, err := loadTemplateSources(ts.pagePatterns)
+	if err != nil {
+		return errors.Wrap(err, "Load pages")
+	}
+
+	pages := map[string]*templateSource{}
+	tmpls := map[string]templateExecutor{}
+	tmpls[""], err = ts.compile(layouts, nil)
+	if err != nil {
+		return err
+	}
+	for _, page := range pageSources {
+		if _, exists := pages[page.name]; exists {
+			return errors.Errorf("Duplicated page name %s: %s", page.name, page.path)
+		}
+		pages[page.name] = page
+		tmpls[page.name], err = ts.compile(layouts, page)
+		if err != nil {
+			return err
+		}
+	}
+
+	ts.Lock()
+	defer ts.Unlock()
+	ts.layouts = layouts
+	ts.pages = pages
+	ts.tmpls = tmpls
+	return nil
+}
+
+func (ts *TemplateSet) compile(layouts []*templateSource, page *templateSource) (templateExecutor, error) {
+	sources := layouts
+	if page != nil {
+		sources = append(sources[:len(sources):len(sources)], page)
+	}
+
+	if ts.html {
+		root := htmlTemplate.New("").Funcs(htmlTemplateFuncs()).Funcs(ts.funcs)
+		for _, src := range sources {
+			if _, err := root.New(src.name).Parse(src.content); err != nil {
+				return nil, errors.Wrapf(err, "Parse %s", src.path)
+			}
+		}
+		return root, nil
+	}
+
+	root := template.New("").Funcs(textTemplateFuncs()).Funcs(ts.funcs)
+	for _, src := range sources {
+		if _, err := root.New(src.name).Parse(src.content); err != nil {
+			return nil, errors.Wrapf(err, "Parse %s", src.path)
+		}
+	}
+	return root, nil
+}
+
+func isRemoteTemplate(pattern string) bool {
+	u, err := url.Parse(pattern)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https")
+}
+
+func loadTemplateSources(patterns []string) ([]*templateSource, error) {
+	var ret []*templateSource
+	for _, pattern := range patterns {
+		if isRemoteTemplate(pattern) {
+			d, err := FetchData(pattern)
+			if err != nil {
+				return nil, errors.Wrapf(err, "Fetch %s", pattern)
+			}
+			u, _ := url.Parse(pattern)
+			ret = append(ret, &templateSource{
+				name:    path.Base(u.Path),
+				path:    pattern,
+				content: string(d),
+			})
+			continue
+		}
+
+		files, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Glob %s", pattern)
+		}
+		if len(files) == 0 {
+			return nil, errors.Errorf("No template matches %s", pattern)
+		}
+		for _, file := range files {
+			info, err := os.Stat(file)
+			if err != nil {
+				return nil, errors.Wrapf(err, "Stat %s", file)
+			}
+			d, err := FetchData(file)
+			if err != nil {
+				return nil, errors.Wrapf(err, "Fetch %s", file)
+			}
+			ret = append(ret, &templateSource{
+				name:    filepath.Base(file),
+				path:    file,
+				content: string(d),
+				modTime: info.ModTime(),
+			})
+		}
+	}
+	return ret, nil
+}
+
+// localTemplateModTimes returns the modified time of all the local files
+// matching the patterns.
+func localTemplateModTimes(patterns []string) map[string]time.Time {
+	ret := map[string]time.Time{}
+	for _, pattern := range patterns {
+		if isRemoteTemplate(pattern) {
+			continue
+		}
+		files, _ := filepath.Glob(pattern)
+		for _, file := range files {
+			if info, err := os.Stat(file); err == nil {
+				ret[file] = info.ModTime()
+			}
+		}
+	}
+	return ret
+}
+
+func (ts *TemplateSet) isModified() bool {
+	current := localTemplateModTimes(append(ts.layoutPatterns[:len(ts.layoutPatterns):len(ts.layoutPatterns)], ts.pagePatterns...))
+
+	ts.RLock()
+	defer ts.RUnlock()
+	n := 0
+	for _, src := range ts.layouts {
+		if src.modTime.IsZero() {
+			continue
+		}
+		n++
+		if t, ok := current[src.path]; !ok || !t.Equal(src.modTime) {
+			return true
+		}
+	}
+	for _, src := range ts.pages {
+		if src.modTime.IsZero() {
+			continue
+		}
+		n++
+		if t, ok := current[src.path]; !ok || !t.Equal(src.modTime) {
+			return true
+		}
+	}
+	return n != len(current)
+}
+
+func (ts *TemplateSet) lookup(page string) (templateExecutor, error) {
+	if IsDebuging() && ts.isModified() {
+		LogDebug("Reload modified templates")
+		if err := ts.Load(); err != nil {
+			return nil, errors.Wrap(err, "Reload templates")
+		}
+	}
+
+	ts.RLock()
+	defer ts.RUnlock()
+	if ts.tmpls == nil {
+		return nil, errors.New("Templates not loaded")
+	}
+	tmpl, ok := ts.tmpls[page]
+	if !ok {
+		return nil, errors.Errorf("No such page: %s", page)
+	}
+	return tmpl, nil
+}
+
+// Execute applies the template of given name to data, and writes the output to w.
+// The name can be either a page, a layout or a partial.
+func (ts *TemplateSet) Execute(w io.Writer, name string, data interface{}) error {
+	page := ""
+	ts.RLock()
+	if _, ok := ts.pages[name]; ok {
+		page = name
+	}
+	ts.RUnlock()
+
+	tmpl, err := ts.lookup(page)
+	if err != nil {
+		return err
+	}
+	return tmpl.ExecuteTemplate(w, name, data)
+}
+
+// ExecuteLayout applies the layout to data, with the blocks overridden by the
+// definitions in the page, and writes the output to w.
+func (ts *TemplateSet) ExecuteLayout(w io.Writer, layout, page string, data interface{}) error {
+	tmpl, err := ts.lookup(page)
+	if err != nil {
+		return err
+	}
+	return tmpl.ExecuteTemplate(w, layout, data)
+}
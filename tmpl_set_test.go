@@ -0,0 +1,100 @@
+package goutils
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeTemplateFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestTemplateSetLayout(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplateFiles(t, dir, map[string]string{
+		"layouts/base.html":   `<body>{{template "header.html" .}}{{block "content" .}}empty{{end}}</body>`,
+		"layouts/header.html": `<h1>{{.Title}}</h1>`,
+		"pages/report.html":   `{{define "content"}}<p>{{.Body}}</p>{{end}}`,
+		"pages/blank.html":    `blank`,
+	})
+
+	ts := NewTemplateSet(
+		WithTemplateLayouts(filepath.Join(dir, "layouts/*.html")),
+		WithTemplatePages(filepath.Join(dir, "pages/*.html")),
+		WithTemplateHTML(true),
+	)
+	if err := ts.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	data := Var{"Title": "Daily", "Body": "<b>"}
+	tests := []struct {
+		layout string
+		page   string
+		want   string
+	}{
+		{"base.html", "report.html", "<body><h1>Daily</h1><p>&lt;b&gt;</p></body>"},
+		{"base.html", "blank.html", "<body><h1>Daily</h1>empty</body>"},
+		{"", "blank.html", "blank"},
+		{"", "header.html", "<h1>Daily</h1>"},
+	}
+	for _, tt := range tests {
+		buf := &bytes.Buffer{}
+		var err error
+		if tt.layout == "" {
+			err = ts.Execute(buf, tt.page, data)
+		} else {
+			err = ts.ExecuteLayout(buf, tt.layout, tt.page, data)
+		}
+		if err != nil {
+			t.Errorf("%s/%s: %v", tt.layout, tt.page, err)
+			continue
+		}
+		if buf.String() != tt.want {
+			t.Errorf("%s/%s = %v, want %v", tt.layout, tt.page, buf.String(), tt.want)
+		}
+	}
+
+	if err := ts.Execute(&bytes.Buffer{}, "missing.html", data); err == nil {
+		t.Error("Expect error executing missing template")
+	}
+}
+
+func TestTemplateSetReload(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplateFiles(t, dir, map[string]string{"hello.txt": "Hello {{.}}"})
+
+	ts := NewTemplateSet(WithTemplatePages(filepath.Join(dir, "*.txt")))
+	if err := ts.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	old := *debug
+	*debug = true
+	defer func() { *debug = old }()
+
+	writeTemplateFiles(t, dir, map[string]string{"hello.txt": "Hi {{.}}"})
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, "hello.txt"), future, future); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := ts.Execute(buf, "hello.txt", "there"); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "Hi there" {
+		t.Errorf("Template not reloaded: %s", buf.String())
+	}
+}
@@ -64,3 +64,59 @@ func TestSprintHTML(t *testing.T) {
 		})
 	}
 }
+
+func TestRenderTextError(t *testing.T) {
+	tests := []struct {
+		name   string
+		tmpl   string
+		data   interface{}
+		strict bool
+		line   int
+		column int
+	}{
+		{"ParseError", "line1\n{{.Name", nil, false, 2, 0},
+		{"ExecuteError", "line1\nline2 {{.Name.Foo}}", Var{"Name": 1}, false, 2, 13},
+		{"MissingKey", "{{.Name}}", Var{}, true, 1, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var err error
+			if tt.strict {
+				_, err = RenderTextStrict(tt.tmpl, tt.data)
+			} else {
+				_, err = RenderText(tt.tmpl, tt.data)
+			}
+			tmplErr, ok := err.(*TemplateError)
+			if !ok {
+				t.Fatalf("Expect *TemplateError, got %v", err)
+			}
+			if tmplErr.Line != tt.line || tmplErr.Column != tt.column {
+				t.Errorf("Position = %d:%d, want %d:%d", tmplErr.Line, tmplErr.Column, tt.line, tt.column)
+			}
+		})
+	}
+
+	if got, err := RenderText("{{.Name}}", Var{}); err != nil || got != "<no value>" {
+		t.Errorf("RenderText() = %v, %v", got, err)
+	}
+}
+
+func TestTemplateCacheBound(t *testing.T) {
+	old := textTmplCache.Capacity()
+	defer textTmplCache.SetCapacity(old)
+	textTmplCache.SetCapacity(2)
+
+	Sprintt("a", nil)
+	Sprintt("b", nil)
+	before, _ := TemplateCacheStats()
+	for _, s := range []string{"c", "a"} {
+		Sprintt(s, nil)
+	}
+	after, _ := TemplateCacheStats()
+	if textTmplCache.Len() != 2 {
+		t.Error("Template cache not bounded", textTmplCache.Len())
+	}
+	if after.Evictions-before.Evictions != 2 {
+		t.Error("Unexpected evictions", after.Evictions-before.Evictions)
+	}
+}